@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// adminClient talks to the installed backend's admin HTTP API using the
+// admin key written by install
+type adminClient struct {
+	baseURL  string
+	adminKey string
+	client   *http.Client
+}
+
+// adminAPIError is the error body returned by the backend
+type adminAPIError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// adminKeyPath is where install writes the admin key
+var adminKeyPath = "/etc/convex/admin.key"
+
+// backendURL returns the URL of the installed backend. CONVEX_BACKEND_URL
+// overrides the default http://localhost:3210.
+func backendURL() string {
+	if env := os.Getenv("CONVEX_BACKEND_URL"); env != "" {
+		return strings.TrimSuffix(env, "/")
+	}
+	return "http://localhost:3210"
+}
+
+// newAdminClient creates a client for the installed backend
+func newAdminClient() (*adminClient, error) {
+	adminKey, err := os.ReadFile(adminKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read admin key (is the backend installed and are you root?): %w", err)
+	}
+
+	return &adminClient{
+		baseURL:  backendURL(),
+		adminKey: strings.TrimSpace(string(adminKey)),
+		client:   &http.Client{Timeout: 5 * time.Minute},
+	}, nil
+}
+
+// newRequest builds an authenticated request against the backend
+func (c *adminClient) newRequest(method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Convex "+c.adminKey)
+	req.Header.Set("Convex-Client", "convex-backend-ops-"+Version)
+	return req, nil
+}
+
+// do sends a JSON request and decodes the JSON response into out (if non-nil)
+func (c *adminClient) do(method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := c.newRequest(method, path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach backend at %s: %w", c.baseURL, err)
+	}
+	defer resp.Body.Close()
+
+	if err := checkAdminResponse(resp); err != nil {
+		return err
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid response from %s: %w", path, err)
+	}
+	return nil
+}
+
+// checkAdminResponse turns non-2xx responses into errors using the
+// backend's {code, message} error body when present
+func checkAdminResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	var apiErr adminAPIError
+	if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+		return fmt.Errorf("backend returned %d %s: %s", resp.StatusCode, apiErr.Code, apiErr.Message)
+	}
+	return fmt.Errorf("backend returned %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+}
+
+// functionResult is the response of the function execution endpoints
+type functionResult struct {
+	Status       string          `json:"status"`
+	Value        json.RawMessage `json:"value"`
+	ErrorMessage string          `json:"errorMessage"`
+	LogLines     []string        `json:"logLines"`
+}
+
+// callFunction calls a function through one of the execution endpoints
+// (/api/query, /api/mutation, /api/action or /api/function for any kind).
+// Function errors are reported in the result, not as an error.
+func (c *adminClient) callFunction(endpoint, path string, args interface{}) (*functionResult, error) {
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+
+	var result functionResult
+	body := map[string]interface{}{"path": path, "args": args, "format": "json"}
+	if err := c.do("POST", endpoint, body, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// query runs a query function, including the backend's _system queries, and
+// decodes its return value into out
+func (c *adminClient) query(path string, args, out interface{}) error {
+	result, err := c.callFunction("/api/query", path, args)
+	if err != nil {
+		return err
+	}
+	if result.Status != "success" {
+		return fmt.Errorf("query %s failed: %s", path, result.ErrorMessage)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(result.Value, out)
+}
+
+// stream sends a request without the client timeout, for transfers whose
+// duration depends on the data size. The caller closes the response body.
+func (c *adminClient) stream(req *http.Request) (*http.Response, error) {
+	client := &http.Client{Transport: c.client.Transport}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach backend at %s: %w", c.baseURL, err)
+	}
+	if err := checkAdminResponse(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,300 @@
+package cmd
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// adminKeyVersion is the first byte of an encrypted admin key
+const adminKeyVersion = 1
+
+// adminKeyPurpose is the KBKDF label used to derive the admin key encryption
+// key from the instance secret
+const adminKeyPurpose = "admin key"
+
+// generateInstanceSecret returns a new random 32-byte instance secret, hex-encoded
+func generateInstanceSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate instance secret: %w", err)
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// generateAdminKey issues an admin key for the instance the way the backend's
+// key broker does: an AdminKey protobuf encrypted with deterministic AES-SIV
+// under a key derived from the instance secret, formatted as
+// "<instanceName>|<hex(version || SIV tag || ciphertext)>".
+// Callers should verify the key against the running backend before relying on it.
+func generateAdminKey(instanceName, instanceSecret string) (string, error) {
+	return encryptAdminKey(instanceName, instanceSecret, uint64(time.Now().Unix()))
+}
+
+// encryptAdminKey encrypts an AdminKey issued at issuedS. The version byte is
+// authenticated as associated data; there is no nonce.
+func encryptAdminKey(instanceName, instanceSecret string, issuedS uint64) (string, error) {
+	key, err := adminKeyEncryptionKey(instanceSecret)
+	if err != nil {
+		return "", err
+	}
+
+	ciphertext, err := sivEncrypt(key, [][]byte{{adminKeyVersion}}, encodeAdminKeyProto(instanceName, issuedS))
+	if err != nil {
+		return "", err
+	}
+
+	encrypted := append([]byte{adminKeyVersion}, ciphertext...)
+	return instanceName + "|" + hex.EncodeToString(encrypted), nil
+}
+
+// decryptAdminKey checks that an admin key was issued under the instance
+// secret and returns the instance name and issue time it carries
+func decryptAdminKey(adminKey, instanceSecret string) (string, uint64, error) {
+	_, body, ok := strings.Cut(strings.TrimSpace(adminKey), "|")
+	if !ok {
+		return "", 0, fmt.Errorf("admin key has no instance name")
+	}
+	encrypted, err := hex.DecodeString(body)
+	if err != nil {
+		return "", 0, fmt.Errorf("admin key is not hex-encoded: %w", err)
+	}
+	if len(encrypted) < 1+aes.BlockSize || encrypted[0] != adminKeyVersion {
+		return "", 0, fmt.Errorf("unsupported admin key format")
+	}
+
+	key, err := adminKeyEncryptionKey(instanceSecret)
+	if err != nil {
+		return "", 0, err
+	}
+	plaintext, err := sivDecrypt(key, [][]byte{{adminKeyVersion}}, encrypted[1:])
+	if err != nil {
+		return "", 0, fmt.Errorf("admin key was not issued for this instance secret")
+	}
+	return decodeAdminKeyProto(plaintext)
+}
+
+// adminKeyEncryptionKey derives the AES-SIV key for admin keys from the
+// hex-encoded instance secret
+func adminKeyEncryptionKey(instanceSecret string) ([]byte, error) {
+	secret, err := hex.DecodeString(strings.TrimSpace(instanceSecret))
+	if err != nil {
+		return nil, fmt.Errorf("instance secret is not hex-encoded: %w", err)
+	}
+	if len(secret) != 32 {
+		return nil, fmt.Errorf("instance secret must be 32 bytes, got %d", len(secret))
+	}
+	return kbkdfCMAC(secret, []byte(adminKeyPurpose), nil, 32)
+}
+
+// encodeAdminKeyProto encodes AdminKey{instance_name = 1, issued_s = 2}
+func encodeAdminKeyProto(instanceName string, issuedS uint64) []byte {
+	var buf []byte
+	buf = append(buf, 1<<3|2)
+	buf = binary.AppendUvarint(buf, uint64(len(instanceName)))
+	buf = append(buf, instanceName...)
+	buf = append(buf, 2<<3|0)
+	buf = binary.AppendUvarint(buf, issuedS)
+	return buf
+}
+
+// decodeAdminKeyProto decodes the fields written by encodeAdminKeyProto
+func decodeAdminKeyProto(buf []byte) (string, uint64, error) {
+	var instanceName string
+	var issuedS uint64
+	for len(buf) > 0 {
+		tag, n := binary.Uvarint(buf)
+		if n <= 0 {
+			return "", 0, fmt.Errorf("malformed admin key")
+		}
+		buf = buf[n:]
+
+		value, n := binary.Uvarint(buf)
+		if n <= 0 {
+			return "", 0, fmt.Errorf("malformed admin key")
+		}
+		buf = buf[n:]
+
+		switch tag {
+		case 1<<3 | 2:
+			if uint64(len(buf)) < value {
+				return "", 0, fmt.Errorf("malformed admin key")
+			}
+			instanceName = string(buf[:value])
+			buf = buf[value:]
+		case 2<<3 | 0:
+			issuedS = value
+		default:
+			return "", 0, fmt.Errorf("unexpected field in admin key")
+		}
+	}
+	return instanceName, issuedS, nil
+}
+
+// kbkdfCMAC derives outLen bytes using the NIST SP 800-108 KDF in counter
+// mode with AES-CMAC as the PRF:
+// K(i) = CMAC(key, [i]_32 || label || 0x00 || context || [L]_32)
+func kbkdfCMAC(key, label, context []byte, outLen int) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []byte
+	for i := uint32(1); len(out) < outLen; i++ {
+		var input []byte
+		input = binary.BigEndian.AppendUint32(input, i)
+		input = append(input, label...)
+		input = append(input, 0)
+		input = append(input, context...)
+		input = binary.BigEndian.AppendUint32(input, uint32(outLen*8))
+		out = append(out, cmacSum(block, input)...)
+	}
+	return out[:outLen], nil
+}
+
+// cmacSum computes AES-CMAC (RFC 4493)
+func cmacSum(block cipher.Block, msg []byte) []byte {
+	k1, k2 := cmacSubkeys(block)
+
+	n := (len(msg) + aes.BlockSize - 1) / aes.BlockSize
+	complete := n > 0 && len(msg)%aes.BlockSize == 0
+	if n == 0 {
+		n = 1
+	}
+
+	last := make([]byte, aes.BlockSize)
+	copy(last, msg[(n-1)*aes.BlockSize:])
+	if complete {
+		xorBytes(last, k1)
+	} else {
+		last[len(msg)-(n-1)*aes.BlockSize] = 0x80
+		xorBytes(last, k2)
+	}
+
+	x := make([]byte, aes.BlockSize)
+	for i := 0; i < n-1; i++ {
+		xorBytes(x, msg[i*aes.BlockSize:(i+1)*aes.BlockSize])
+		block.Encrypt(x, x)
+	}
+	xorBytes(x, last)
+	block.Encrypt(x, x)
+	return x
+}
+
+func cmacSubkeys(block cipher.Block) ([]byte, []byte) {
+	l := make([]byte, aes.BlockSize)
+	block.Encrypt(l, l)
+	k1 := dbl(l)
+	return k1, dbl(k1)
+}
+
+// dbl multiplies a block by x in GF(2^128)
+func dbl(b []byte) []byte {
+	out := make([]byte, len(b))
+	var carry byte
+	for i := len(b) - 1; i >= 0; i-- {
+		out[i] = b[i]<<1 | carry
+		carry = b[i] >> 7
+	}
+	if carry != 0 {
+		out[len(out)-1] ^= 0x87
+	}
+	return out
+}
+
+func xorBytes(dst, src []byte) {
+	subtle.XORBytes(dst, dst, src)
+}
+
+// sivEncrypt implements AES-SIV (RFC 5297). The key is split into the CMAC
+// key and the CTR key; the output is the synthetic IV followed by the ciphertext.
+func sivEncrypt(key []byte, ad [][]byte, plaintext []byte) ([]byte, error) {
+	if len(key) != 32 && len(key) != 48 && len(key) != 64 {
+		return nil, fmt.Errorf("invalid AES-SIV key length %d", len(key))
+	}
+
+	macBlock, err := aes.NewCipher(key[:len(key)/2])
+	if err != nil {
+		return nil, err
+	}
+	ctrBlock, err := aes.NewCipher(key[len(key)/2:])
+	if err != nil {
+		return nil, err
+	}
+
+	v := s2v(macBlock, ad, plaintext)
+
+	// Clear the 31st and 63rd bits of the IV before using it as the counter
+	q := make([]byte, aes.BlockSize)
+	copy(q, v)
+	q[8] &= 0x7f
+	q[12] &= 0x7f
+
+	out := make([]byte, aes.BlockSize+len(plaintext))
+	copy(out, v)
+	cipher.NewCTR(ctrBlock, q).XORKeyStream(out[aes.BlockSize:], plaintext)
+	return out, nil
+}
+
+// sivDecrypt reverses sivEncrypt and authenticates the plaintext and
+// associated data against the synthetic IV
+func sivDecrypt(key []byte, ad [][]byte, ciphertext []byte) ([]byte, error) {
+	if len(key) != 32 && len(key) != 48 && len(key) != 64 {
+		return nil, fmt.Errorf("invalid AES-SIV key length %d", len(key))
+	}
+	if len(ciphertext) < aes.BlockSize {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+
+	macBlock, err := aes.NewCipher(key[:len(key)/2])
+	if err != nil {
+		return nil, err
+	}
+	ctrBlock, err := aes.NewCipher(key[len(key)/2:])
+	if err != nil {
+		return nil, err
+	}
+
+	v := ciphertext[:aes.BlockSize]
+	q := make([]byte, aes.BlockSize)
+	copy(q, v)
+	q[8] &= 0x7f
+	q[12] &= 0x7f
+
+	plaintext := make([]byte, len(ciphertext)-aes.BlockSize)
+	cipher.NewCTR(ctrBlock, q).XORKeyStream(plaintext, ciphertext[aes.BlockSize:])
+
+	if subtle.ConstantTimeCompare(s2v(macBlock, ad, plaintext), v) != 1 {
+		return nil, fmt.Errorf("authentication failed")
+	}
+	return plaintext, nil
+}
+
+// s2v is the S2V construction from RFC 5297
+func s2v(block cipher.Block, ad [][]byte, plaintext []byte) []byte {
+	d := cmacSum(block, make([]byte, aes.BlockSize))
+	for _, s := range ad {
+		d = dbl(d)
+		xorBytes(d, cmacSum(block, s))
+	}
+
+	var t []byte
+	if len(plaintext) >= aes.BlockSize {
+		t = append([]byte{}, plaintext...)
+		xorBytes(t[len(t)-aes.BlockSize:], d)
+	} else {
+		t = dbl(d)
+		padded := make([]byte, aes.BlockSize)
+		copy(padded, plaintext)
+		padded[len(plaintext)] = 0x80
+		xorBytes(t, padded)
+	}
+	return cmacSum(block, t)
+}
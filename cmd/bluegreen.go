@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// stagingDir holds the side-by-side copy used to validate a blue/green upgrade
+const stagingDir = "/var/lib/convex/staging"
+
+// validateStaged starts the new backend binary on a temporary port against a
+// copy of the backed up data and runs the health checks for the new bundle.
+// The running backend is not touched; the staged copy is always removed.
+func validateStaged(bundlePath, backupDir string, manifest *Manifest, port int) error {
+	// A process already on either port would answer the health checks
+	// in place of the staged backend
+	for _, p := range []int{port, port + 1} {
+		if pid := portOwner(p); pid != 0 {
+			return fmt.Errorf("port %d is already in use by %s (pid %d); choose another with --staging-port", p, processName(pid), pid)
+		}
+	}
+
+	if err := os.RemoveAll(stagingDir); err != nil {
+		return fmt.Errorf("failed to clean staging directory: %w", err)
+	}
+	defer os.RemoveAll(stagingDir)
+
+	// Copy the backup snapshot so the new version can migrate it freely
+	dataDir := filepath.Join(stagingDir, "data")
+	if err := copyDir(filepath.Join(backupDir, "data"), dataDir); err != nil {
+		return fmt.Errorf("failed to copy data for staging: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Join(dataDir, "storage"), 0755); err != nil {
+		return fmt.Errorf("failed to create staging storage: %w", err)
+	}
+
+	binary := filepath.Join(stagingDir, "convex-backend")
+	if err := copyFile(filepath.Join(bundlePath, "backend"), binary); err != nil {
+		return fmt.Errorf("failed to copy new binary: %w", err)
+	}
+	if err := os.Chmod(binary, 0755); err != nil {
+		return fmt.Errorf("failed to set binary permissions: %w", err)
+	}
+
+	creds, err := readInstalledCredentials()
+	if err != nil {
+		return err
+	}
+
+	logPath := filepath.Join(stagingDir, "backend.log")
+	logFile, err := os.Create(logPath)
+	if err != nil {
+		return fmt.Errorf("failed to create staging log: %w", err)
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(binary,
+		filepath.Join(dataDir, "convex.db"),
+		"--port", strconv.Itoa(port),
+		"--site-proxy-port", strconv.Itoa(port+1),
+		"--instance-name", instanceNameFromAdminKey(creds.AdminKey),
+		"--instance-secret", creds.InstanceSecret,
+		"--local-storage", filepath.Join(dataDir, "storage"),
+	)
+	cmd.Dir = stagingDir
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start staged backend: %w", err)
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	defer stopStaged(cmd, exited)
+
+	settings, err := loadHealthSettingsFor(manifest)
+	if err != nil {
+		return err
+	}
+	settings.backendURL = fmt.Sprintf("http://localhost:%d", port)
+	settings.siteURL = fmt.Sprintf("http://localhost:%d", port+1)
+
+	// Stop polling, and wait for the poller, if the staged backend exits first
+	ctx, cancel := context.WithCancel(context.Background())
+	healthErr := make(chan error, 1)
+	polled := make(chan struct{})
+	go func() {
+		defer close(polled)
+		healthErr <- waitForHealthWith(ctx, settings)
+	}()
+	defer func() {
+		cancel()
+		<-polled
+	}()
+
+	select {
+	case err := <-exited:
+		exited <- err
+		showStagedLogs(logPath)
+		return fmt.Errorf("staged backend exited before becoming healthy: %v", err)
+	case err := <-healthErr:
+		if err != nil {
+			showStagedLogs(logPath)
+			return err
+		}
+	}
+
+	if pid := portOwner(port); pid != cmd.Process.Pid {
+		return fmt.Errorf("port %d is served by pid %d, not the staged backend (pid %d)", port, pid, cmd.Process.Pid)
+	}
+	return nil
+}
+
+// stopStaged terminates the staged backend, killing it if it does not exit
+func stopStaged(cmd *exec.Cmd, exited chan error) {
+	cmd.Process.Signal(syscall.SIGTERM)
+	select {
+	case <-exited:
+	case <-time.After(10 * time.Second):
+		cmd.Process.Kill()
+		<-exited
+	}
+}
+
+func showStagedLogs(logPath string) {
+	data, err := os.ReadFile(logPath)
+	if err != nil || len(data) == 0 {
+		return
+	}
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) > 20 {
+		lines = lines[len(lines)-20:]
+	}
+
+	printError("Recent staged backend logs:")
+	for _, line := range lines {
+		fmt.Fprintln(os.Stderr, redactKnownSecrets(line))
+	}
+}
@@ -0,0 +1,548 @@
+package cmd
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// BundleReport represents the result of inspecting a bundle directory
+type BundleReport struct {
+	Path        string                 `json:"path"`
+	Valid       bool                   `json:"valid"`
+	Manifest    *Manifest              `json:"manifest,omitempty"`
+	Apps        []string               `json:"apps"`
+	Backend     *BundleBackendInfo     `json:"backend,omitempty"`
+	Database    *BundleDatabaseInfo    `json:"database,omitempty"`
+	Storage     BundleStorageInfo      `json:"storage"`
+	Functions   *BundleFunctionsInfo   `json:"functions,omitempty"`
+	Credentials *BundleCredentialsInfo `json:"credentials,omitempty"`
+	Errors      []string               `json:"errors"`
+	Warnings    []string               `json:"warnings"`
+
+	// missing lists the required files that are absent; install and
+	// upgrade only refuse a bundle for these
+	missing []string
+}
+
+// BundleBackendInfo describes the backend binary in a bundle
+type BundleBackendInfo struct {
+	Size       int64  `json:"size"`
+	Executable bool   `json:"executable"`
+	Class      string `json:"class"`
+	Machine    string `json:"machine"`
+	Type       string `json:"type"`
+}
+
+// BundleDatabaseInfo describes the SQLite database in a bundle
+type BundleDatabaseInfo struct {
+	Size      int64  `json:"size"`
+	SizeHuman string `json:"sizeHuman"`
+	PageSize  int    `json:"pageSize,omitempty"`
+	PageCount uint32 `json:"pageCount,omitempty"`
+	Empty     bool   `json:"empty"`
+}
+
+// BundleStorageInfo summarizes the storage directory in a bundle
+type BundleStorageInfo struct {
+	Present   bool   `json:"present"`
+	Files     int    `json:"files"`
+	Size      int64  `json:"size"`
+	SizeHuman string `json:"sizeHuman"`
+}
+
+// BundleFunctionsInfo summarizes the functions package of an apps bundle
+type BundleFunctionsInfo struct {
+	Modules   int    `json:"modules"`
+	Size      int64  `json:"size"`
+	SizeHuman string `json:"sizeHuman"`
+}
+
+// BundleCredentialsInfo describes credentials.json without exposing the secrets
+type BundleCredentialsInfo struct {
+	InstanceName string `json:"instanceName"`
+}
+
+// Known bundle platforms and the ELF machine each one requires
+var bundlePlatformMachines = map[string]elf.Machine{
+	"linux-x64":   elf.EM_X86_64,
+	"linux-amd64": elf.EM_X86_64,
+	"linux-arm64": elf.EM_AARCH64,
+}
+
+var bundleCmd = &cobra.Command{
+	Use:   "bundle",
+	Short: "Inspect and validate bundles",
+	Long:  `Inspect and validate bundle directories created by convex-bundler.`,
+}
+
+var bundleValidateCmd = &cobra.Command{
+	Use:   "validate <path>",
+	Short: "Validate a bundle directory",
+	Long: `Validate a bundle directory before shipping or installing it.
+
+Checks manifest.json and credentials.json against their schema, verifies that
+backend is an executable ELF binary for the bundle platform, checks that
+convex.db is a SQLite database and summarizes the storage directory.
+
+Exits with a non-zero status if the bundle has errors.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBundleValidate,
+}
+
+var bundleInspectCmd = &cobra.Command{
+	Use:   "inspect <path>",
+	Short: "Display detailed bundle information",
+	Long:  `Display the manifest, apps, backend binary, database and storage details of a bundle directory.`,
+	Args:  cobra.ExactArgs(1),
+	RunE:  runBundleInspect,
+}
+
+func init() {
+	rootCmd.AddCommand(bundleCmd)
+	bundleCmd.AddCommand(bundleValidateCmd)
+	bundleCmd.AddCommand(bundleInspectCmd)
+}
+
+func runBundleValidate(cmd *cobra.Command, args []string) error {
+	report := inspectBundle(args[0])
+
+	if flagJSON {
+		if err := printJSON(report); err != nil {
+			return err
+		}
+	} else {
+		printBundleReport(report)
+	}
+
+	if !report.Valid {
+		return fmt.Errorf("bundle has %d error(s)", len(report.Errors))
+	}
+	return nil
+}
+
+func runBundleInspect(cmd *cobra.Command, args []string) error {
+	report := inspectBundle(args[0])
+
+	if flagJSON {
+		return printJSON(report)
+	}
+
+	printBundleReport(report)
+	return nil
+}
+
+func printBundleReport(report *BundleReport) {
+	fmt.Println("Bundle Report")
+	fmt.Println("=============")
+	fmt.Println()
+	fmt.Printf("Path:     %s\n", report.Path)
+	if report.Manifest != nil {
+		fmt.Printf("Name:     %s\n", report.Manifest.Name)
+		fmt.Printf("Version:  %s\n", report.Manifest.Version)
+		if report.Manifest.isAppsOnly() {
+			fmt.Println("Type:     apps only")
+		} else {
+			fmt.Printf("Platform: %s\n", report.Manifest.Platform)
+		}
+		fmt.Printf("Created:  %s\n", report.Manifest.CreatedAt)
+	}
+	fmt.Println()
+
+	if len(report.Apps) > 0 {
+		fmt.Println("Bundled Apps:")
+		for _, app := range report.Apps {
+			fmt.Printf("  - %s\n", app)
+		}
+		fmt.Println()
+	}
+
+	if report.Backend != nil {
+		fmt.Printf("Backend:  %s, %s %s (%s)\n", humanizeBytes(report.Backend.Size), report.Backend.Class, report.Backend.Machine, report.Backend.Type)
+	}
+	if report.Database != nil {
+		if report.Database.Empty {
+			fmt.Println("Database: empty")
+		} else {
+			fmt.Printf("Database: %s, %d pages of %d bytes\n", report.Database.SizeHuman, report.Database.PageCount, report.Database.PageSize)
+		}
+	}
+	if report.Functions != nil {
+		fmt.Printf("Functions: %d modules (%s)\n", report.Functions.Modules, report.Functions.SizeHuman)
+	}
+	if report.Storage.Present {
+		fmt.Printf("Storage:  %d files (%s)\n", report.Storage.Files, report.Storage.SizeHuman)
+	} else {
+		fmt.Println("Storage:  (none)")
+	}
+	if report.Credentials != nil {
+		fmt.Printf("Instance: %s\n", report.Credentials.InstanceName)
+	}
+	fmt.Println()
+
+	for _, w := range report.Warnings {
+		fmt.Printf("Warning: %s\n", w)
+	}
+	for _, e := range report.Errors {
+		fmt.Printf("Error:   %s\n", e)
+	}
+	if len(report.Warnings) > 0 || len(report.Errors) > 0 {
+		fmt.Println()
+	}
+
+	if report.Valid {
+		printSuccess("Bundle is valid")
+	} else {
+		fmt.Printf("Bundle is INVALID (%d errors)\n", len(report.Errors))
+	}
+}
+
+// inspectBundle runs every bundle check and collects the results into a report.
+// Problems are recorded in the report rather than returned so that a single
+// run shows everything that is wrong with the bundle.
+func inspectBundle(bundlePath string) *BundleReport {
+	return inspectBundleFor(bundlePath, false)
+}
+
+// inspectBundleFor inspects a bundle for an operation that takes its
+// credentials from elsewhere when credentialsProvided is set, e.g. install
+// with --generate-credentials or --secrets-from, so credentials.json is optional
+func inspectBundleFor(bundlePath string, credentialsProvided bool) *BundleReport {
+	report := &BundleReport{
+		Path:     bundlePath,
+		Apps:     []string{},
+		Errors:   []string{},
+		Warnings: []string{},
+	}
+
+	if info, err := os.Stat(bundlePath); err != nil || !info.IsDir() {
+		report.addError("bundle directory not found: %s", bundlePath)
+		report.missing = append(report.missing, bundlePath)
+		return report
+	}
+
+	report.Manifest = inspectBundleManifest(report, filepath.Join(bundlePath, "manifest.json"))
+	if report.Manifest != nil && report.Manifest.Apps != nil {
+		report.Apps = report.Manifest.Apps
+	}
+
+	// Apps bundles only carry a functions package for the running backend
+	required := []string{"backend", "convex.db", "manifest.json", "credentials.json"}
+	if report.Manifest != nil && report.Manifest.isAppsOnly() {
+		required = []string{"manifest.json", "functions"}
+	} else if credentialsProvided || (report.Manifest != nil && (report.Manifest.GenerateCredentials || report.Manifest.SecretsFrom != "")) {
+		// Credentials are generated or fetched on each host, so the bundle need not ship any
+		required = []string{"backend", "convex.db", "manifest.json"}
+	}
+	for _, f := range required {
+		if _, err := os.Stat(filepath.Join(bundlePath, f)); os.IsNotExist(err) {
+			report.addError("missing required file: %s", f)
+			report.missing = append(report.missing, f)
+		}
+	}
+
+	report.Functions = inspectBundleFunctions(report, filepath.Join(bundlePath, "functions"))
+	report.Credentials = inspectBundleCredentials(report, filepath.Join(bundlePath, "credentials.json"))
+	report.Backend = inspectBundleBackend(report, filepath.Join(bundlePath, "backend"))
+	report.Database = inspectBundleDatabase(report, filepath.Join(bundlePath, "convex.db"))
+	report.Storage = inspectBundleStorage(filepath.Join(bundlePath, "storage"))
+
+	report.Valid = len(report.Errors) == 0
+	return report
+}
+
+func (r *BundleReport) addError(format string, args ...interface{}) {
+	r.Errors = append(r.Errors, fmt.Sprintf(format, args...))
+}
+
+func (r *BundleReport) addWarning(format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
+
+func inspectBundleManifest(report *BundleReport, path string) *Manifest {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		report.addError("manifest.json is not a JSON object: %v", err)
+		return nil
+	}
+
+	manifest, unknown, err := parseManifest(data)
+	if err != nil {
+		report.addError("manifest.json has invalid field types: %v", err)
+		return nil
+	}
+
+	for _, field := range unknown {
+		report.addWarning("manifest.json: unknown field %q (may require a newer convex-backend-ops)", field)
+	}
+
+	switch manifest.Type {
+	case "", bundleTypeFull, bundleTypeApps:
+	default:
+		report.addError("manifest.json: unknown bundle type %q (expected %q or %q)", manifest.Type, bundleTypeFull, bundleTypeApps)
+	}
+
+	requiredFields := []string{"name", "version", "platform", "createdAt"}
+	if manifest.isAppsOnly() {
+		requiredFields = []string{"name", "version", "createdAt"}
+	}
+	for _, field := range requiredFields {
+		if _, ok := raw[field]; !ok {
+			report.addError("manifest.json: missing required field %q", field)
+		}
+	}
+
+	if _, ok := raw["name"]; ok && strings.TrimSpace(manifest.Name) == "" {
+		report.addError("manifest.json: name must not be empty")
+	}
+	if _, ok := raw["version"]; ok && !isValidVersion(manifest.Version) {
+		report.addError("manifest.json: version %q is not a semantic version", manifest.Version)
+	}
+	if _, ok := raw["platform"]; ok {
+		if _, known := bundlePlatformMachines[manifest.Platform]; !known {
+			report.addError("manifest.json: unsupported platform %q", manifest.Platform)
+		}
+	}
+	if _, ok := raw["createdAt"]; ok {
+		if _, err := time.Parse(time.RFC3339, manifest.CreatedAt); err != nil {
+			report.addError("manifest.json: createdAt %q is not an RFC 3339 timestamp", manifest.CreatedAt)
+		}
+	}
+	if _, ok := raw["apps"]; !ok {
+		report.addWarning("manifest.json: no apps listed")
+	}
+
+	if manifest.SchemaVersion < 0 {
+		report.addError("manifest.json: invalid schemaVersion %d", manifest.SchemaVersion)
+	} else if manifest.effectiveSchemaVersion() > manifestSchemaVersion {
+		report.addError("manifest.json: schema version %d is newer than supported version %d", manifest.SchemaVersion, manifestSchemaVersion)
+	}
+
+	if manifest.MinOpsVersion != "" {
+		if cmp, err := compareVersions(opsVersion(), manifest.MinOpsVersion); err == nil && cmp < 0 {
+			report.addWarning("bundle requires convex-backend-ops >= %s (running %s)", manifest.MinOpsVersion, Version)
+		} else if !isValidVersion(manifest.MinOpsVersion) {
+			report.addError("manifest.json: minOpsVersion %q is not a semantic version", manifest.MinOpsVersion)
+		}
+	}
+
+	if manifest.SecretsFrom != "" {
+		if _, err := parseSecretSource(manifest.SecretsFrom, ""); err != nil {
+			report.addError("manifest.json: secretsFrom: %v", err)
+		}
+		if manifest.GenerateCredentials {
+			report.addError("manifest.json: generateCredentials and secretsFrom cannot both be set")
+		}
+	}
+
+	if manifest.Health != nil {
+		if err := (&healthSettings{}).apply(manifest.Health); err != nil {
+			report.addError("manifest.json: health: %v", err)
+		}
+		for _, check := range manifest.Health.Checks {
+			if check.Path == "" {
+				report.addError("manifest.json: health check %q has no path", check.Name)
+			}
+		}
+	}
+
+	for _, r := range manifest.UpgradeFrom {
+		if _, err := parseVersionRange(r); err != nil {
+			report.addError("manifest.json: upgradeFrom: %v", err)
+		}
+	}
+
+	return manifest
+}
+
+func inspectBundleCredentials(report *BundleReport, path string) *BundleCredentialsInfo {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var creds Credentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		report.addError("credentials.json is invalid: %v", err)
+		return nil
+	}
+
+	if creds.AdminKey == "" {
+		report.addError("credentials.json: adminKey must not be empty")
+	}
+	if creds.InstanceSecret == "" {
+		report.addError("credentials.json: instanceSecret must not be empty")
+	} else if _, err := hex.DecodeString(creds.InstanceSecret); err != nil {
+		report.addError("credentials.json: instanceSecret is not hex-encoded")
+	}
+
+	info := &BundleCredentialsInfo{}
+	if idx := strings.Index(creds.AdminKey, "|"); idx > 0 {
+		info.InstanceName = creds.AdminKey[:idx]
+	} else if creds.AdminKey != "" {
+		report.addError("credentials.json: adminKey is not in the form <instanceName>|<key>")
+	}
+
+	return info
+}
+
+func inspectBundleFunctions(report *BundleReport, path string) *BundleFunctionsInfo {
+	if _, err := os.Stat(path); err != nil {
+		return nil
+	}
+
+	pkg, err := loadFunctionsPackage(path)
+	if err != nil {
+		report.addError("functions: %v", err)
+		return nil
+	}
+
+	return &BundleFunctionsInfo{
+		Modules:   len(pkg.Modules),
+		Size:      pkg.size(),
+		SizeHuman: humanizeBytes(pkg.size()),
+	}
+}
+
+func inspectBundleBackend(report *BundleReport, path string) *BundleBackendInfo {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+
+	info := &BundleBackendInfo{
+		Size:       stat.Size(),
+		Executable: stat.Mode()&0111 != 0,
+	}
+	if !info.Executable {
+		report.addWarning("backend is not marked executable (it will be made executable on install)")
+	}
+
+	f, err := elf.Open(path)
+	if err != nil {
+		report.addError("backend is not an ELF binary: %v", err)
+		return info
+	}
+	defer f.Close()
+
+	info.Class = f.Class.String()
+	info.Machine = f.Machine.String()
+	info.Type = f.Type.String()
+
+	if f.Type != elf.ET_EXEC && f.Type != elf.ET_DYN {
+		report.addError("backend is not an executable (ELF type %s)", f.Type)
+	}
+
+	if report.Manifest != nil {
+		if want, ok := bundlePlatformMachines[report.Manifest.Platform]; ok && want != f.Machine {
+			report.addWarning("backend is built for %s but manifest platform is %s", f.Machine, report.Manifest.Platform)
+		}
+	}
+
+	return info
+}
+
+func inspectBundleDatabase(report *BundleReport, path string) *BundleDatabaseInfo {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		report.addError("failed to stat convex.db: %v", err)
+		return nil
+	}
+
+	info := &BundleDatabaseInfo{
+		Size:      stat.Size(),
+		SizeHuman: humanizeBytes(stat.Size()),
+	}
+
+	// SQLite treats a zero-length file as an empty database
+	if stat.Size() == 0 {
+		info.Empty = true
+		report.addWarning("convex.db is empty, the backend will start without pre-deployed apps")
+		return info
+	}
+
+	pageSize, pageCount, err := readSQLiteHeader(f)
+	if err != nil {
+		report.addError("convex.db is not a valid SQLite database: %v", err)
+		return info
+	}
+	info.PageSize = pageSize
+	info.PageCount = pageCount
+
+	if pageCount > 0 && int64(pageSize)*int64(pageCount) != stat.Size() {
+		report.addWarning("convex.db size (%d bytes) does not match its header (%d pages of %d bytes)", stat.Size(), pageCount, pageSize)
+	}
+
+	return info
+}
+
+// readSQLiteHeader parses the 100-byte SQLite database header and returns
+// the page size and the in-header database size in pages.
+func readSQLiteHeader(r io.Reader) (int, uint32, error) {
+	header := make([]byte, 100)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, 0, fmt.Errorf("file too short for SQLite header")
+	}
+
+	if !bytes.Equal(header[:16], []byte("SQLite format 3\x00")) {
+		return 0, 0, fmt.Errorf("bad magic header")
+	}
+
+	// Page size is a big-endian uint16 where 1 means 65536
+	pageSize := int(binary.BigEndian.Uint16(header[16:18]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+	if pageSize < 512 || pageSize&(pageSize-1) != 0 {
+		return 0, 0, fmt.Errorf("invalid page size %d", pageSize)
+	}
+
+	pageCount := binary.BigEndian.Uint32(header[28:32])
+	return pageSize, pageCount, nil
+}
+
+func inspectBundleStorage(path string) BundleStorageInfo {
+	info := BundleStorageInfo{SizeHuman: humanizeBytes(0)}
+
+	stat, err := os.Stat(path)
+	if err != nil || !stat.IsDir() {
+		return info
+	}
+	info.Present = true
+
+	filepath.Walk(path, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		// .gitkeep placeholders are not storage objects
+		if !fi.IsDir() && fi.Name() != ".gitkeep" {
+			info.Files++
+			info.Size += fi.Size()
+		}
+		return nil
+	})
+	info.SizeHuman = humanizeBytes(info.Size)
+
+	return info
+}
@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// writeTestBundle creates a bundle directory that passes validation, using the
+// running test binary as the backend so the ELF checks have a real executable.
+func writeTestBundle(t *testing.T) string {
+	t.Helper()
+
+	if runtime.GOOS != "linux" {
+		t.Skip("bundle backend checks require an ELF test binary")
+	}
+
+	dir := t.TempDir()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := copyFile(exe, filepath.Join(dir, "backend")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(filepath.Join(dir, "backend"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		"manifest.json":     `{"name":"Test","version":"1.0.0","apps":["./app"],"platform":"linux-x64","createdAt":"2025-12-30T01:14:24Z"}`,
+		"credentials.json":  `{"adminKey":"test|abcdef","instanceSecret":"0123abcd"}`,
+		"storage/blob-1":    "hello",
+		"storage/sub/blob2": "world!",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "convex.db"), sqliteImage(4096, 2), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return dir
+}
+
+func sqliteImage(pageSize int, pages uint32) []byte {
+	buf := make([]byte, pageSize*int(pages))
+	copy(buf, "SQLite format 3\x00")
+	binary.BigEndian.PutUint16(buf[16:18], uint16(pageSize))
+	binary.BigEndian.PutUint32(buf[28:32], pages)
+	return buf
+}
+
+func TestInspectBundle_Valid(t *testing.T) {
+	dir := writeTestBundle(t)
+
+	report := inspectBundle(dir)
+	if !report.Valid {
+		t.Fatalf("expected valid bundle, got errors: %v", report.Errors)
+	}
+	if report.Credentials == nil || report.Credentials.InstanceName != "test" {
+		t.Errorf("unexpected credentials info: %+v", report.Credentials)
+	}
+	if report.Database == nil || report.Database.PageSize != 4096 || report.Database.PageCount != 2 {
+		t.Errorf("unexpected database info: %+v", report.Database)
+	}
+	if report.Storage.Files != 2 || report.Storage.Size != 11 {
+		t.Errorf("unexpected storage info: %+v", report.Storage)
+	}
+	if len(report.Apps) != 1 || report.Apps[0] != "./app" {
+		t.Errorf("unexpected apps: %v", report.Apps)
+	}
+}
+
+func TestInspectBundle_Invalid(t *testing.T) {
+	dir := writeTestBundle(t)
+
+	os.WriteFile(filepath.Join(dir, "backend"), []byte("#!/bin/sh\n"), 0755)
+	os.WriteFile(filepath.Join(dir, "convex.db"), []byte("not a database, definitely not a database at all, padding padding padding padding padding padding"), 0644)
+	os.WriteFile(filepath.Join(dir, "manifest.json"), []byte(`{"name":"Test","version":"latest","platform":"windows"}`), 0644)
+
+	report := inspectBundle(dir)
+	if report.Valid {
+		t.Fatal("expected invalid bundle")
+	}
+
+	errs := strings.Join(report.Errors, "\n")
+	for _, want := range []string{"not an ELF binary", "not a valid SQLite database", "not a semantic version", "unsupported platform", `missing required field "createdAt"`} {
+		if !strings.Contains(errs, want) {
+			t.Errorf("expected error containing %q, got:\n%s", want, errs)
+		}
+	}
+}
+
+func TestValidateBundle_OnlyRequiredFilesBlock(t *testing.T) {
+	dir := writeTestBundle(t)
+	os.WriteFile(filepath.Join(dir, "manifest.json"), []byte(`{"name":"Test","version":"latest","platform":"windows"}`), 0644)
+
+	if inspectBundle(dir).Valid {
+		t.Fatal("expected bundle validate to reject the manifest")
+	}
+	if _, err := validateBundle(dir, false); err != nil {
+		t.Errorf("manifest problems should only warn on install: %v", err)
+	}
+
+	os.Remove(filepath.Join(dir, "convex.db"))
+	_, err := validateBundle(dir, false)
+	if err == nil || !strings.Contains(err.Error(), "missing required file: convex.db") {
+		t.Errorf("expected missing convex.db to block install, got %v", err)
+	}
+}
+
+// install and upgrade take the manifest from the same pass that warns about
+// fields this build does not understand
+func TestValidateBundle_ReturnsManifestAndWarnsUnknownFields(t *testing.T) {
+	dir := writeTestBundle(t)
+	os.WriteFile(filepath.Join(dir, "manifest.json"), []byte(`{"name":"Test","version":"1.0.0","apps":[],"platform":"linux-x64","createdAt":"2025-12-30T01:14:24Z","rollout":"canary"}`), 0644)
+
+	manifest, err := validateBundle(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest == nil || manifest.Version != "1.0.0" {
+		t.Fatalf("expected the bundle manifest, got %+v", manifest)
+	}
+
+	warnings := strings.Join(inspectBundleFor(dir, false).Warnings, "\n")
+	if !strings.Contains(warnings, `unknown field "rollout"`) {
+		t.Errorf("expected a warning for the unknown field, got:\n%s", warnings)
+	}
+}
+
+func TestInspectBundle_EmptyDatabaseIsWarning(t *testing.T) {
+	dir := writeTestBundle(t)
+	os.WriteFile(filepath.Join(dir, "convex.db"), nil, 0644)
+
+	report := inspectBundle(dir)
+	if !report.Valid {
+		t.Fatalf("empty database should not invalidate bundle: %v", report.Errors)
+	}
+	if report.Database == nil || !report.Database.Empty {
+		t.Errorf("expected empty database, got %+v", report.Database)
+	}
+}
+
+func TestReadSQLiteHeader(t *testing.T) {
+	pageSize, pages, err := readSQLiteHeader(bytes.NewReader(sqliteImage(1024, 3)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pageSize != 1024 || pages != 3 {
+		t.Errorf("got page size %d, pages %d", pageSize, pages)
+	}
+
+	if _, _, err := readSQLiteHeader(strings.NewReader("short")); err == nil {
+		t.Error("expected error for short header")
+	}
+}
+
+func TestInspectBundle_AppsOnly(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "manifest.json"), []byte(`{"type":"apps","name":"Test","version":"1.1.0","apps":["./app"],"createdAt":"2025-12-30T01:14:24Z"}`), 0644)
+
+	report := inspectBundle(dir)
+	if report.Valid || !strings.Contains(strings.Join(report.Errors, "\n"), "missing required file: functions") {
+		t.Fatalf("expected missing functions error, got %v", report.Errors)
+	}
+
+	writeTestFunctions(t, filepath.Join(dir, "functions"), map[string]string{"messages.js": "export const list = 1;"})
+
+	report = inspectBundle(dir)
+	if !report.Valid {
+		t.Fatalf("expected valid apps bundle, got errors: %v", report.Errors)
+	}
+	if report.Functions == nil || report.Functions.Modules != 1 {
+		t.Errorf("unexpected functions info: %+v", report.Functions)
+	}
+}
+
+func TestInspectBundle_GenerateCredentials(t *testing.T) {
+	dir := writeTestBundle(t)
+	os.Remove(filepath.Join(dir, "credentials.json"))
+
+	if inspectBundle(dir).Valid {
+		t.Fatal("expected bundle without credentials.json to be invalid")
+	}
+
+	os.WriteFile(filepath.Join(dir, "manifest.json"), []byte(`{"name":"Test","version":"1.0.0","apps":["./app"],"platform":"linux-x64","createdAt":"2025-12-30T01:14:24Z","generateCredentials":true}`), 0644)
+	if report := inspectBundle(dir); !report.Valid {
+		t.Fatalf("expected valid bundle, got errors: %v", report.Errors)
+	}
+}
+
+// install --secrets-from or --generate-credentials on a bundle that ships no
+// credentials.json
+func TestValidateBundle_CredentialsFromFlags(t *testing.T) {
+	dir := writeTestBundle(t)
+	os.Remove(filepath.Join(dir, "credentials.json"))
+
+	if _, err := validateBundle(dir, false); err == nil || !strings.Contains(err.Error(), "credentials.json") {
+		t.Fatalf("expected missing credentials.json to block install from the bundle, got %v", err)
+	}
+
+	installSecretsFrom = "env:CONVEX_"
+	defer func() { installSecretsFrom = "" }()
+	if _, err := validateBundle(dir, installCredentialsFromFlags()); err != nil {
+		t.Errorf("expected the bundle to be accepted with --secrets-from: %v", err)
+	}
+
+	installSecretsFrom, installGenerateCredentials = "", true
+	defer func() { installGenerateCredentials = false }()
+	if _, err := validateBundle(dir, installCredentialsFromFlags()); err != nil {
+		t.Errorf("expected the bundle to be accepted with --generate-credentials: %v", err)
+	}
+}
+
+func TestInspectBundle_SecretsFrom(t *testing.T) {
+	dir := writeTestBundle(t)
+	os.Remove(filepath.Join(dir, "credentials.json"))
+
+	os.WriteFile(filepath.Join(dir, "manifest.json"), []byte(`{"name":"Test","version":"1.0.0","apps":["./app"],"platform":"linux-x64","createdAt":"2025-12-30T01:14:24Z","secretsFrom":"keystore:/etc/convex/credentials.keystore"}`), 0644)
+	if report := inspectBundle(dir); !report.Valid {
+		t.Fatalf("expected valid bundle, got errors: %v", report.Errors)
+	}
+
+	os.WriteFile(filepath.Join(dir, "manifest.json"), []byte(`{"name":"Test","version":"1.0.0","apps":["./app"],"platform":"linux-x64","createdAt":"2025-12-30T01:14:24Z","secretsFrom":"s3:bucket"}`), 0644)
+	if inspectBundle(dir).Valid {
+		t.Fatal("expected unknown secret source to be invalid")
+	}
+}
@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Nagios plugin states, which are also the exit codes of the check command
+const (
+	checkOK       = 0
+	checkWarning  = 1
+	checkCritical = 2
+	checkUnknown  = 3
+)
+
+var checkStateNames = map[int]string{
+	checkOK:       "OK",
+	checkWarning:  "WARNING",
+	checkCritical: "CRITICAL",
+	checkUnknown:  "UNKNOWN",
+}
+
+// checkSeverity orders states from best to worst when combining results
+var checkSeverity = map[int]int{
+	checkOK:       0,
+	checkUnknown:  1,
+	checkWarning:  2,
+	checkCritical: 3,
+}
+
+// CheckResult is the outcome of one monitored item
+type CheckResult struct {
+	Name    string `json:"name"`
+	State   string `json:"state"`
+	Message string `json:"message"`
+}
+
+// CheckOutput represents JSON output for the check command
+type CheckOutput struct {
+	State    string        `json:"state"`
+	ExitCode int           `json:"exitCode"`
+	Summary  string        `json:"summary"`
+	Checks   []CheckResult `json:"checks"`
+	PerfData string        `json:"perfData"`
+}
+
+// checkThresholds are the limits the check command evaluates against. Zero
+// disables a limit.
+type checkThresholds struct {
+	diskWarning      int
+	diskCritical     int
+	restartsWarning  int
+	restartsCritical int
+	backupWarning    time.Duration
+	backupCritical   time.Duration
+}
+
+var (
+	checkLimits          checkThresholds
+	checkBackupWarningS  string
+	checkBackupCriticalS string
+)
+
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Monitoring check with Nagios-style exit codes",
+	Long: `Evaluate the installation for monitoring systems such as Nagios, Icinga or
+any tool that runs Nagios plugins.
+
+Checks the service state, health checks, free disk space on the data volume,
+restarts since the service was last started and (if thresholds are given) the
+age of the newest backup. Prints one line with performance data and exits with
+0 (OK), 1 (WARNING), 2 (CRITICAL) or 3 (UNKNOWN).
+
+Backup age thresholds accept Go durations plus a "d" suffix for days, e.g. 7d.`,
+	Args: cobra.NoArgs,
+	Run:  runCheck,
+}
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+	checkCmd.Flags().IntVar(&checkLimits.diskWarning, "disk-warning", 20, "Warn when free disk space on the data volume is at or below this percentage")
+	checkCmd.Flags().IntVar(&checkLimits.diskCritical, "disk-critical", 10, "Critical when free disk space is at or below this percentage")
+	checkCmd.Flags().IntVar(&checkLimits.restartsWarning, "restarts-warning", 1, "Warn at this many service restarts")
+	checkCmd.Flags().IntVar(&checkLimits.restartsCritical, "restarts-critical", 3, "Critical at this many service restarts")
+	checkCmd.Flags().StringVar(&checkBackupWarningS, "backup-warning", "", "Warn when the newest backup is older than this (e.g. 7d, disabled by default)")
+	checkCmd.Flags().StringVar(&checkBackupCriticalS, "backup-critical", "", "Critical when the newest backup is older than this (disabled by default)")
+}
+
+// runCheck exits with the check state rather than returning an error, so
+// usage problems are reported as UNKNOWN like any Nagios plugin
+func runCheck(cmd *cobra.Command, args []string) {
+	var err error
+	if checkLimits.backupWarning, err = parseAgeThreshold(checkBackupWarningS); err == nil {
+		checkLimits.backupCritical, err = parseAgeThreshold(checkBackupCriticalS)
+	}
+	if err != nil {
+		fmt.Printf("CONVEX UNKNOWN - %v\n", err)
+		os.Exit(checkUnknown)
+	}
+
+	output := evaluateCheck(collectStatus(), checkLimits)
+	if flagJSON {
+		printJSON(output)
+	} else {
+		fmt.Printf("CONVEX %s - %s | %s\n", output.State, output.Summary, output.PerfData)
+	}
+	os.Exit(output.ExitCode)
+}
+
+// evaluateCheck turns a status snapshot into a check result
+func evaluateCheck(status StatusOutput, limits checkThresholds) CheckOutput {
+	var checks []CheckResult
+	add := func(name string, state int, format string, args ...interface{}) {
+		checks = append(checks, CheckResult{Name: name, State: checkStateNames[state], Message: fmt.Sprintf(format, args...)})
+	}
+	var perf []string
+
+	if !status.Installed {
+		add("installed", checkCritical, "not installed")
+		return combineChecks(checks, perf)
+	}
+
+	if status.ServiceStatus == "active" {
+		add("service", checkOK, "service active")
+	} else {
+		add("service", checkCritical, "service %s", status.ServiceStatus)
+	}
+
+	switch status.Health {
+	case "healthy":
+		add("health", checkOK, "healthy")
+	case "unhealthy":
+		failed := firstFailedCheck(status.HealthChecks)
+		add("health", checkCritical, "health check %s failed: %s", failed.Name, failed.Error)
+	default:
+		msg := "health unknown"
+		if len(status.HealthChecks) > 0 && status.HealthChecks[0].Error != "" {
+			msg += ": " + status.HealthChecks[0].Error
+		}
+		add("health", checkUnknown, "%s", msg)
+	}
+	var latency int64
+	for _, hc := range status.HealthChecks {
+		if hc.LatencyMs > latency {
+			latency = hc.LatencyMs
+		}
+	}
+	perf = append(perf, fmt.Sprintf("health_latency=%dms;;;0", latency))
+
+	if s := status.Storage; s != nil && s.DiskTotalBytes > 0 {
+		state := checkOK
+		if limits.diskCritical > 0 && s.DiskFreePercentage <= limits.diskCritical {
+			state = checkCritical
+		} else if limits.diskWarning > 0 && s.DiskFreePercentage <= limits.diskWarning {
+			state = checkWarning
+		}
+		add("disk", state, "disk %d%% free (%s)", s.DiskFreePercentage, humanizeBytes(s.DiskFreeBytes))
+		perf = append(perf,
+			fmt.Sprintf("disk_free=%d%%;%s;%s;0;100", s.DiskFreePercentage, perfLimit(limits.diskWarning), perfLimit(limits.diskCritical)),
+			fmt.Sprintf("database_size=%dB;;;0", s.DatabaseBytes),
+			fmt.Sprintf("storage_size=%dB;;;0", s.StorageBytes))
+	}
+
+	if p := status.Process; p != nil {
+		state := checkOK
+		if limits.restartsCritical > 0 && p.Restarts >= limits.restartsCritical {
+			state = checkCritical
+		} else if limits.restartsWarning > 0 && p.Restarts >= limits.restartsWarning {
+			state = checkWarning
+		}
+		add("restarts", state, "%d restarts", p.Restarts)
+		perf = append(perf,
+			fmt.Sprintf("restarts=%d;%s;%s;0", p.Restarts, perfLimit(limits.restartsWarning), perfLimit(limits.restartsCritical)),
+			fmt.Sprintf("memory=%dB;;;0", p.MemoryBytes))
+	}
+
+	if limits.backupWarning > 0 || limits.backupCritical > 0 {
+		if b := status.LastBackup; b == nil {
+			state := checkWarning
+			if limits.backupCritical > 0 {
+				state = checkCritical
+			}
+			add("backup", state, "no backups")
+		} else {
+			age := time.Duration(b.AgeSeconds) * time.Second
+			state := checkOK
+			if limits.backupCritical > 0 && age > limits.backupCritical {
+				state = checkCritical
+			} else if limits.backupWarning > 0 && age > limits.backupWarning {
+				state = checkWarning
+			}
+			add("backup", state, "last backup %s ago", formatAge(age))
+		}
+	}
+	if b := status.LastBackup; b != nil {
+		perf = append(perf, fmt.Sprintf("backup_age=%ds;%s;%s;0", b.AgeSeconds,
+			perfLimit(int(limits.backupWarning.Seconds())), perfLimit(int(limits.backupCritical.Seconds()))))
+	}
+
+	return combineChecks(checks, perf)
+}
+
+// combineChecks reports the worst state. The summary lists the problems, or
+// every item when all are OK.
+func combineChecks(checks []CheckResult, perf []string) CheckOutput {
+	worst := checkOK
+	var problems, all []string
+	for _, c := range checks {
+		state := checkStateCode(c.State)
+		if checkSeverity[state] > checkSeverity[worst] {
+			worst = state
+		}
+		if state != checkOK {
+			problems = append(problems, c.Message)
+		}
+		all = append(all, c.Message)
+	}
+
+	summary := strings.Join(all, ", ")
+	if len(problems) > 0 {
+		summary = strings.Join(problems, ", ")
+	}
+
+	return CheckOutput{
+		State:    checkStateNames[worst],
+		ExitCode: worst,
+		Summary:  summary,
+		Checks:   checks,
+		PerfData: strings.Join(perf, " "),
+	}
+}
+
+func checkStateCode(name string) int {
+	for code, n := range checkStateNames {
+		if n == name {
+			return code
+		}
+	}
+	return checkUnknown
+}
+
+// perfLimit formats a threshold for perfdata, leaving disabled limits empty
+func perfLimit(v int) string {
+	if v <= 0 {
+		return ""
+	}
+	return strconv.Itoa(v)
+}
+
+// parseAgeThreshold parses a duration that may use a "d" (days) suffix.
+// An empty string disables the threshold.
+func parseAgeThreshold(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid backup age threshold %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid backup age threshold %q", s)
+	}
+	return d, nil
+}
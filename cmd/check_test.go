@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func healthyTestStatus() StatusOutput {
+	return StatusOutput{
+		Installed:     true,
+		Manifest:      &Manifest{Name: "My Backend", Version: "1.5.0"},
+		ServiceStatus: "active",
+		Health:        "healthy",
+		HealthChecks:  []HealthCheckResult{{Name: "version", Passed: true, LatencyMs: 12}},
+		Process:       &ServiceProcess{PID: 4242, MemoryBytes: 1024},
+		Storage:       &StorageStatus{DiskFreeBytes: 45 << 30, DiskTotalBytes: 100 << 30, DiskFreePercentage: 45},
+		LastBackup:    &LastBackupStatus{Version: "1.4.0", AgeSeconds: 2 * 86400},
+	}
+}
+
+var defaultTestLimits = checkThresholds{diskWarning: 20, diskCritical: 10, restartsWarning: 1, restartsCritical: 3}
+
+func TestEvaluateCheck_OK(t *testing.T) {
+	output := evaluateCheck(healthyTestStatus(), defaultTestLimits)
+	if output.ExitCode != checkOK || output.State != "OK" {
+		t.Fatalf("expected OK, got %+v", output)
+	}
+	for _, want := range []string{"health_latency=12ms;;;0", "disk_free=45%;20;10;0;100", "restarts=0;1;3;0", "backup_age=172800s;;;0"} {
+		if !strings.Contains(output.PerfData, want) {
+			t.Errorf("perfdata %q is missing %q", output.PerfData, want)
+		}
+	}
+}
+
+func TestEvaluateCheck_Thresholds(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*StatusOutput)
+		limits checkThresholds
+		want   int
+	}{
+		{"disk warning", func(s *StatusOutput) { s.Storage.DiskFreePercentage = 15 }, defaultTestLimits, checkWarning},
+		{"disk critical", func(s *StatusOutput) { s.Storage.DiskFreePercentage = 5 }, defaultTestLimits, checkCritical},
+		{"restarts warning", func(s *StatusOutput) { s.Process.Restarts = 1 }, defaultTestLimits, checkWarning},
+		{"restarts critical", func(s *StatusOutput) { s.Process.Restarts = 5 }, defaultTestLimits, checkCritical},
+		{"service down", func(s *StatusOutput) { s.ServiceStatus = "failed"; s.Process = nil }, defaultTestLimits, checkCritical},
+		{"unhealthy", func(s *StatusOutput) {
+			s.Health = "unhealthy"
+			s.HealthChecks = []HealthCheckResult{{Name: "version", Error: "connection refused"}}
+		}, defaultTestLimits, checkCritical},
+		{"health unknown", func(s *StatusOutput) { s.Health = "unknown" }, defaultTestLimits, checkUnknown},
+		{"backup too old", func(s *StatusOutput) {}, checkThresholds{backupWarning: 24 * time.Hour}, checkWarning},
+		{"no backup", func(s *StatusOutput) { s.LastBackup = nil }, checkThresholds{backupWarning: time.Hour, backupCritical: 2 * time.Hour}, checkCritical},
+		{"not installed", func(s *StatusOutput) { s.Installed = false }, defaultTestLimits, checkCritical},
+	}
+
+	for _, tt := range tests {
+		status := healthyTestStatus()
+		tt.modify(&status)
+		if got := evaluateCheck(status, tt.limits); got.ExitCode != tt.want {
+			t.Errorf("%s: exit code %d (%s), want %d", tt.name, got.ExitCode, got.Summary, tt.want)
+		}
+	}
+}
+
+func TestEvaluateCheck_WorstStateWins(t *testing.T) {
+	status := healthyTestStatus()
+	status.Storage.DiskFreePercentage = 15
+	status.ServiceStatus = "failed"
+
+	output := evaluateCheck(status, defaultTestLimits)
+	if output.ExitCode != checkCritical {
+		t.Fatalf("expected CRITICAL, got %s", output.State)
+	}
+	if output.Summary != "service failed, disk 15% free (45.0 GB)" {
+		t.Errorf("unexpected summary %q", output.Summary)
+	}
+}
+
+func TestParseAgeThreshold(t *testing.T) {
+	tests := map[string]time.Duration{"": 0, "7d": 7 * 24 * time.Hour, "36h": 36 * time.Hour}
+	for in, want := range tests {
+		if got, err := parseAgeThreshold(in); err != nil || got != want {
+			t.Errorf("parseAgeThreshold(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := parseAgeThreshold("a week"); err == nil {
+		t.Error("expected an error for an invalid threshold")
+	}
+}
@@ -0,0 +1,480 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// credentialsBackupsDir holds the previous credentials after each rotation
+const credentialsBackupsDir = "/var/lib/convex/credentials-backups"
+
+// credentialFiles are the files rewritten by a rotation, by backup file name
+var credentialFiles = map[string]string{
+	"admin.key":              "/etc/convex/admin.key",
+	"instance.secret":        "/etc/convex/instance.secret",
+	"convex-backend.service": "/etc/systemd/system/convex-backend.service",
+}
+
+// CredentialsRotateOutput represents JSON output for credentials rotate. The
+// admin key is redacted; use credentials show --reveal to display it.
+type CredentialsRotateOutput struct {
+	InstanceName string `json:"instanceName"`
+	AdminKey     string `json:"adminKey"`
+	BackupDir    string `json:"backupDir"`
+}
+
+// CredentialsShowOutput represents the output of credentials show
+type CredentialsShowOutput struct {
+	InstanceName   string `json:"instanceName"`
+	AdminKey       string `json:"adminKey"`
+	InstanceSecret string `json:"instanceSecret"`
+	Redacted       bool   `json:"redacted"`
+}
+
+var (
+	credentialsReveal         bool
+	credentialsFormat         string
+	credentialsSecretsFrom    string
+	credentialsPassphraseFile string
+)
+
+var credentialsCmd = &cobra.Command{
+	Use:   "credentials",
+	Short: "Manage the admin key and instance secret",
+}
+
+var credentialsRotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "Generate a new instance secret and admin key",
+	Long: `Generate a new instance secret and derive a new admin key for the instance.
+
+The new credentials are written to /etc/convex and the systemd unit, the backend
+is restarted, health-checked and the new admin key is verified against the
+admin API. If any step fails the previous credentials are restored.
+
+With --secrets-from the new credentials are read from an external source (see
+'install --help') instead of being generated, e.g. after rotating them in Vault.
+
+The previous credentials are kept under /var/lib/convex/credentials-backups so
+the rotation can be undone with 'credentials revert'. Every existing admin key
+stops working after a rotation.`,
+	Args: cobra.NoArgs,
+	RunE: runCredentialsRotate,
+}
+
+var credentialsSealCmd = &cobra.Command{
+	Use:   "seal <keystore>",
+	Short: "Encrypt credentials into a keystore file",
+	Long: `Encrypt the installed credentials (or those from --secrets-from) into a
+keystore file unlocked by --passphrase-file. Install and rotate read it with
+--secrets-from keystore:<path>.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCredentialsSeal,
+}
+
+var credentialsShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the installed admin key and instance secret",
+	Long: `Show the installed admin key and instance secret.
+
+Secrets are redacted unless --reveal is given. Every reveal is recorded in
+/var/lib/convex/audit.log. Use --format env to print shell variable assignments
+or --format json for scripts.`,
+	Args: cobra.NoArgs,
+	RunE: runCredentialsShow,
+}
+
+var credentialsRevertCmd = &cobra.Command{
+	Use:   "revert [backup]",
+	Short: "Restore the credentials from before a rotation",
+	Long:  `Restore the credentials saved by 'credentials rotate' (the most recent backup by default).`,
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runCredentialsRevert,
+}
+
+func init() {
+	rootCmd.AddCommand(credentialsCmd)
+	credentialsCmd.AddCommand(credentialsRotateCmd)
+	credentialsCmd.AddCommand(credentialsRevertCmd)
+	credentialsCmd.AddCommand(credentialsShowCmd)
+	credentialsCmd.AddCommand(credentialsSealCmd)
+	credentialsCmd.PersistentFlags().StringVar(&credentialsSecretsFrom, "secrets-from", "", "Read the credentials from an external source (rotate, seal)")
+	credentialsCmd.PersistentFlags().StringVar(&credentialsPassphraseFile, "passphrase-file", "", "File containing the keystore passphrase")
+	credentialsShowCmd.Flags().BoolVar(&credentialsReveal, "reveal", false, "Show the full secrets (recorded in the audit log)")
+	credentialsShowCmd.Flags().StringVar(&credentialsFormat, "format", "text", "Output format: text, env or json")
+	credentialsCmd.PersistentFlags().DurationVar(&flagHealthTimeout, "health-timeout", 0, "How long to wait for health checks to pass (default from health config, 30s)")
+}
+
+func runCredentialsRotate(cmd *cobra.Command, args []string) error {
+	if err := checkRoot(); err != nil {
+		return err
+	}
+	if err := checkSystemd(); err != nil {
+		return err
+	}
+
+	current, err := readInstalledCredentials()
+	if err != nil {
+		return fmt.Errorf("Convex backend is not installed: %w", err)
+	}
+	instanceName := instanceNameFromAdminKey(current.AdminKey)
+
+	if !flagYes {
+		fmt.Printf("This will replace the admin key and instance secret of instance %q.\n", instanceName)
+		fmt.Println("Existing admin keys will stop working.")
+		fmt.Print("Type 'yes' to confirm: ")
+
+		reader := bufio.NewReader(os.Stdin)
+		input, _ := reader.ReadString('\n')
+		if strings.TrimSpace(input) != "yes" {
+			return fmt.Errorf("rotation cancelled")
+		}
+	}
+
+	next, err := nextCredentials(current, instanceName)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(current.InstanceSecret) == next.InstanceSecret && strings.TrimSpace(current.AdminKey) == next.AdminKey {
+		return fmt.Errorf("%s holds the installed credentials, nothing to rotate", credentialsSecretsFrom)
+	}
+	instanceName = instanceNameFromAdminKey(next.AdminKey)
+	adminKey := next.AdminKey
+
+	printInfo("Backing up current credentials...")
+	backupDir, err := backupCredentials()
+	if err != nil {
+		return fmt.Errorf("failed to back up credentials: %w", err)
+	}
+
+	printInfo("Writing new credentials...")
+	if err := writeCredentials(next); err != nil {
+		return revertAfterFailedRotation(backupDir, err)
+	}
+	if err := installSystemdService(); err != nil {
+		return revertAfterFailedRotation(backupDir, fmt.Errorf("failed to update systemd service: %w", err))
+	}
+
+	printInfo("Restarting service...")
+	if err := restartAndVerifyCredentials(); err != nil {
+		showServiceLogs()
+		return revertAfterFailedRotation(backupDir, err)
+	}
+
+	writeAuditLog("credentials.rotate", map[string]string{"backupDir": backupDir})
+	recordInstalledFilesOrWarn("credentials rotate")
+
+	output := CredentialsRotateOutput{
+		InstanceName: instanceName,
+		AdminKey:     redactAdminKey(adminKey),
+		BackupDir:    backupDir,
+	}
+	if flagJSON {
+		return printJSON(output)
+	}
+
+	printSuccess("Credentials rotated")
+	fmt.Println()
+	fmt.Printf("Admin Key:    %s\n", output.AdminKey)
+	fmt.Printf("Backup:       %s\n", backupDir)
+	fmt.Println()
+	fmt.Println("Show the full admin key with: convex-backend-ops credentials show --reveal")
+	fmt.Println("Undo with: convex-backend-ops credentials revert")
+	return nil
+}
+
+// nextCredentials returns the credentials to rotate to: read from
+// --secrets-from, or freshly generated for the instance
+func nextCredentials(current *Credentials, instanceName string) (*Credentials, error) {
+	if credentialsSecretsFrom != "" {
+		provider, err := parseSecretSource(credentialsSecretsFrom, credentialsPassphraseFile)
+		if err != nil {
+			return nil, err
+		}
+		printInfo("Reading credentials from %s...", provider.Name())
+		creds, err := provider.Load()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read credentials: %w", err)
+		}
+		return creds, nil
+	}
+
+	// A key derived the same way as the installed one is accepted by the
+	// backend; refuse before anything is replaced if the installed key
+	// does not decrypt under its own secret
+	if _, _, err := decryptAdminKey(current.AdminKey, current.InstanceSecret); err != nil {
+		return nil, fmt.Errorf("the installed admin key cannot be decrypted with its instance secret (%v), so a generated key may be rejected by the backend; rotate with --secrets-from instead", err)
+	}
+
+	secret, err := generateInstanceSecret()
+	if err != nil {
+		return nil, err
+	}
+	adminKey, err := generateAdminKey(instanceName, secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate admin key: %w", err)
+	}
+	return &Credentials{AdminKey: adminKey, InstanceSecret: secret}, nil
+}
+
+func runCredentialsSeal(cmd *cobra.Command, args []string) error {
+	passphrase, err := readPassphraseFile(credentialsPassphraseFile)
+	if err != nil {
+		return err
+	}
+
+	var creds *Credentials
+	if credentialsSecretsFrom != "" {
+		provider, err := parseSecretSource(credentialsSecretsFrom, credentialsPassphraseFile)
+		if err != nil {
+			return err
+		}
+		if creds, err = provider.Load(); err != nil {
+			return fmt.Errorf("failed to read credentials from %s: %w", provider.Name(), err)
+		}
+	} else {
+		if err := checkRoot(); err != nil {
+			return err
+		}
+		installed, err := readInstalledCredentials()
+		if err != nil {
+			return fmt.Errorf("Convex backend is not installed: %w", err)
+		}
+		creds = &Credentials{
+			AdminKey:       strings.TrimSpace(installed.AdminKey),
+			InstanceSecret: strings.TrimSpace(installed.InstanceSecret),
+		}
+	}
+
+	data, err := sealKeystore(creds, passphrase)
+	if err != nil {
+		return fmt.Errorf("failed to seal keystore: %w", err)
+	}
+	if err := os.WriteFile(args[0], data, 0600); err != nil {
+		return fmt.Errorf("failed to write keystore: %w", err)
+	}
+
+	writeAuditLog("credentials.seal", map[string]string{"keystore": args[0]})
+	printSuccess("Credentials for instance %q sealed into %s", instanceNameFromAdminKey(creds.AdminKey), args[0])
+	return nil
+}
+
+func runCredentialsShow(cmd *cobra.Command, args []string) error {
+	if err := checkRoot(); err != nil {
+		return err
+	}
+
+	format := credentialsFormat
+	if flagJSON {
+		format = "json"
+	}
+	if format != "text" && format != "env" && format != "json" {
+		return fmt.Errorf("unknown format %q (expected text, env or json)", format)
+	}
+
+	creds, err := readInstalledCredentials()
+	if err != nil {
+		return fmt.Errorf("Convex backend is not installed: %w", err)
+	}
+
+	output := CredentialsShowOutput{
+		InstanceName:   instanceNameFromAdminKey(creds.AdminKey),
+		AdminKey:       redactAdminKey(creds.AdminKey),
+		InstanceSecret: redactSecret(creds.InstanceSecret),
+		Redacted:       true,
+	}
+
+	if credentialsReveal {
+		// Refuse to reveal secrets that cannot be audited
+		if err := writeAuditLog("credentials.reveal", map[string]string{"format": format}); err != nil {
+			return fmt.Errorf("not revealing credentials: %w", err)
+		}
+		output.AdminKey = strings.TrimSpace(creds.AdminKey)
+		output.InstanceSecret = strings.TrimSpace(creds.InstanceSecret)
+		output.Redacted = false
+	}
+
+	return printCredentials(output, format)
+}
+
+func printCredentials(output CredentialsShowOutput, format string) error {
+	switch format {
+	case "json":
+		return printJSON(output)
+	case "env":
+		fmt.Printf("CONVEX_INSTANCE_NAME=%s\n", output.InstanceName)
+		fmt.Printf("CONVEX_ADMIN_KEY=%s\n", output.AdminKey)
+		fmt.Printf("CONVEX_INSTANCE_SECRET=%s\n", output.InstanceSecret)
+	default:
+		fmt.Printf("Instance Name:   %s\n", output.InstanceName)
+		fmt.Printf("Admin Key:       %s\n", output.AdminKey)
+		fmt.Printf("Instance Secret: %s\n", output.InstanceSecret)
+		if output.Redacted {
+			fmt.Println()
+			fmt.Println("Secrets are redacted. Use --reveal to show them.")
+		}
+	}
+	return nil
+}
+
+func runCredentialsRevert(cmd *cobra.Command, args []string) error {
+	if err := checkRoot(); err != nil {
+		return err
+	}
+	if err := checkSystemd(); err != nil {
+		return err
+	}
+
+	var backupDir string
+	if len(args) > 0 {
+		dir, err := credentialsBackupPath(args[0])
+		if err != nil {
+			return err
+		}
+		backupDir = dir
+	} else {
+		latest, err := latestCredentialsBackup()
+		if err != nil {
+			return err
+		}
+		backupDir = latest
+	}
+
+	printInfo("Restoring credentials from %s...", backupDir)
+	if err := restoreCredentials(backupDir); err != nil {
+		return err
+	}
+
+	printInfo("Restarting service...")
+	if err := restartAndVerifyCredentials(); err != nil {
+		showServiceLogs()
+		return fmt.Errorf("credentials restored but backend is not healthy: %w", err)
+	}
+
+	writeAuditLog("credentials.revert", map[string]string{"backupDir": backupDir})
+	recordInstalledFilesOrWarn("credentials revert")
+	printSuccess("Credentials restored from %s", backupDir)
+	return nil
+}
+
+// revertAfterFailedRotation restores the previous credentials and returns
+// the rotation error
+func revertAfterFailedRotation(backupDir string, rotateErr error) error {
+	printError("Rotation failed: %v", rotateErr)
+	printInfo("Restoring previous credentials...")
+
+	if err := restoreCredentials(backupDir); err != nil {
+		return fmt.Errorf("restore also failed: %w (original error: %v)", err, rotateErr)
+	}
+	if err := restartAndVerifyCredentials(); err != nil {
+		return fmt.Errorf("restored previous credentials but backend is not healthy: %w (original error: %v)", err, rotateErr)
+	}
+
+	return fmt.Errorf("rotation failed, previous credentials restored: %w", rotateErr)
+}
+
+// restartAndVerifyCredentials restarts the backend, waits for it to become
+// healthy and checks that it accepts the admin key in /etc/convex/admin.key
+func restartAndVerifyCredentials() error {
+	if err := backendService.restart(); err != nil {
+		return fmt.Errorf("failed to restart service: %w", err)
+	}
+	if err := waitForHealth(); err != nil {
+		return err
+	}
+	return verifyAdminKey()
+}
+
+// verifyAdminKey checks that the backend accepts the installed admin key
+func verifyAdminKey() error {
+	client, err := newAdminClient()
+	if err != nil {
+		return err
+	}
+	if _, err := client.listEnvVars(); err != nil {
+		return fmt.Errorf("backend rejected the admin key: %w", err)
+	}
+	return nil
+}
+
+// backupCredentials copies the current credential files to a new timestamped
+// directory under credentialsBackupsDir
+func backupCredentials() (string, error) {
+	// Nanosecond names keep rotations in the same second apart; Mkdir refuses
+	// to reuse a directory rather than overwrite an earlier backup
+	if err := os.MkdirAll(credentialsBackupsDir, 0700); err != nil {
+		return "", err
+	}
+	backupDir := filepath.Join(credentialsBackupsDir, time.Now().UTC().Format("20060102T150405.000000000Z"))
+	if err := os.Mkdir(backupDir, 0700); err != nil {
+		return "", err
+	}
+
+	for name, path := range credentialFiles {
+		if err := copyFile(path, filepath.Join(backupDir, name)); err != nil {
+			return "", fmt.Errorf("failed to back up %s: %w", path, err)
+		}
+		if err := os.Chmod(filepath.Join(backupDir, name), 0600); err != nil {
+			return "", err
+		}
+	}
+
+	return backupDir, nil
+}
+
+// restoreCredentials copies the credential files back from a backup
+func restoreCredentials(backupDir string) error {
+	for name, path := range credentialFiles {
+		if err := copyFile(filepath.Join(backupDir, name), path); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", path, err)
+		}
+	}
+
+	for _, path := range []string{"/etc/convex/admin.key", "/etc/convex/instance.secret"} {
+		if err := os.Chmod(path, 0600); err != nil {
+			return err
+		}
+	}
+	if err := os.Chmod("/etc/systemd/system/convex-backend.service", 0644); err != nil {
+		return err
+	}
+
+	return daemonReload()
+}
+
+// credentialsBackupPath resolves a backup name given to 'credentials revert'.
+// Names are single directory entries under credentialsBackupsDir.
+func credentialsBackupPath(name string) (string, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsRune(name, filepath.Separator) {
+		return "", fmt.Errorf("invalid backup name %q: expected a directory name from %s", name, credentialsBackupsDir)
+	}
+	return filepath.Join(credentialsBackupsDir, name), nil
+}
+
+func latestCredentialsBackup() (string, error) {
+	entries, err := os.ReadDir(credentialsBackupsDir)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read %s: %w", credentialsBackupsDir, err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	if len(names) == 0 {
+		return "", fmt.Errorf("no credential backups found in %s", credentialsBackupsDir)
+	}
+
+	// Backup names are UTC timestamps, so the last one is the newest
+	sort.Strings(names)
+	return filepath.Join(credentialsBackupsDir, names[len(names)-1]), nil
+}
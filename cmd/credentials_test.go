@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCredentialsBackupPath(t *testing.T) {
+	dir, err := credentialsBackupPath("20250101T120000.000000000Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir != filepath.Join(credentialsBackupsDir, "20250101T120000.000000000Z") {
+		t.Errorf("unexpected backup path %q", dir)
+	}
+
+	for _, name := range []string{"", ".", "..", "../../etc", "a/b", "/etc/convex"} {
+		if _, err := credentialsBackupPath(name); err == nil {
+			t.Errorf("expected %q to be rejected", name)
+		}
+	}
+}
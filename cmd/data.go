@@ -0,0 +1,475 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// DataExportOutput represents JSON output for data export
+type DataExportOutput struct {
+	Path           string `json:"path"`
+	Size           int64  `json:"size"`
+	SizeHuman      string `json:"sizeHuman"`
+	SnapshotTs     string `json:"snapshotTs"`
+	IncludeStorage bool   `json:"includeStorage"`
+}
+
+// DataImportOutput represents JSON output for data import
+type DataImportOutput struct {
+	Path        string `json:"path"`
+	Format      string `json:"format"`
+	Mode        string `json:"mode"`
+	Table       string `json:"table,omitempty"`
+	ImportID    string `json:"importId"`
+	RowsWritten int64  `json:"rowsWritten"`
+}
+
+// exportState is the result of the _system/cli/exports:getLatest query
+type exportState struct {
+	State        string      `json:"state"`
+	StartTs      json.Number `json:"start_ts"`
+	ErrorMessage string      `json:"error_message"`
+}
+
+// importState is the result of the _system/cli/queryImport query
+type importState struct {
+	State            string      `json:"state"`
+	MessageToConfirm string      `json:"message_to_confirm"`
+	ProgressMessage  string      `json:"progress_message"`
+	NumRowsWritten   json.Number `json:"num_rows_written"`
+	ErrorMessage     string      `json:"error_message"`
+}
+
+var (
+	dataExportPath           string
+	dataExportIncludeStorage bool
+	dataImportTable          string
+	dataImportReplace        bool
+	dataImportAppend         bool
+	dataTimeout              time.Duration
+)
+
+// dataPollInterval is how often export and import progress is checked
+var dataPollInterval = time.Second
+
+// importPartSize is the size of each uploaded part of an import file, so
+// large snapshots are never held in memory
+var importPartSize = 8 << 20
+
+// importFormats maps file extensions to the backend's import formats
+var importFormats = map[string]string{
+	".zip":   "zip",
+	".jsonl": "jsonLines",
+	".json":  "jsonArray",
+	".csv":   "csv",
+}
+
+var dataCmd = &cobra.Command{
+	Use:   "data",
+	Short: "Export and import app data",
+	Long: `Export and import app data through the backend's snapshot APIs.
+
+Snapshots are ZIP files containing every table and, optionally, file storage.
+They can be moved between installations without copying the SQLite database.`,
+}
+
+var dataExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export a snapshot of all tables",
+	Args:  cobra.NoArgs,
+	RunE:  runDataExport,
+}
+
+var dataImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import a snapshot or table file",
+	Long: `Import a snapshot ZIP, or a single table from a .jsonl, .json or .csv file.
+
+By default the import fails if any imported table already contains documents.
+Use --append to add documents to existing tables or --replace to replace the
+contents of the imported tables.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDataImport,
+}
+
+func init() {
+	rootCmd.AddCommand(dataCmd)
+	dataCmd.AddCommand(dataExportCmd)
+	dataCmd.AddCommand(dataImportCmd)
+	dataExportCmd.Flags().StringVarP(&dataExportPath, "output", "o", "", "Path of the snapshot ZIP to write (required)")
+	dataExportCmd.Flags().BoolVar(&dataExportIncludeStorage, "include-storage", true, "Include file storage in the snapshot")
+	dataExportCmd.MarkFlagRequired("output")
+	dataImportCmd.Flags().StringVar(&dataImportTable, "table", "", "Table to import into (required for .jsonl, .json and .csv files)")
+	dataImportCmd.Flags().BoolVar(&dataImportReplace, "replace", false, "Replace the contents of the imported tables")
+	dataImportCmd.Flags().BoolVar(&dataImportAppend, "append", false, "Append to the imported tables")
+	for _, cmd := range []*cobra.Command{dataExportCmd, dataImportCmd} {
+		cmd.Flags().DurationVar(&dataTimeout, "timeout", time.Hour, "How long to wait for the backend to finish")
+	}
+}
+
+func runDataExport(cmd *cobra.Command, args []string) error {
+	client, err := newAdminClient()
+	if err != nil {
+		return err
+	}
+
+	dataInfo("Requesting snapshot export...")
+	path := fmt.Sprintf("/api/export/request/zip?includeStorage=%t", dataExportIncludeStorage)
+	if err := client.do("POST", path, nil, nil); err != nil {
+		return fmt.Errorf("failed to request export: %w", err)
+	}
+
+	state, err := waitForExport(client)
+	if err != nil {
+		return err
+	}
+
+	size, err := downloadExport(client, state.StartTs.String(), dataExportPath)
+	if err != nil {
+		return err
+	}
+
+	output := DataExportOutput{
+		Path:           dataExportPath,
+		Size:           size,
+		SizeHuman:      humanizeBytes(size),
+		SnapshotTs:     state.StartTs.String(),
+		IncludeStorage: dataExportIncludeStorage,
+	}
+	if flagJSON {
+		return printJSON(output)
+	}
+	printSuccess("Exported snapshot to %s (%s)", output.Path, output.SizeHuman)
+	return nil
+}
+
+// waitForExport polls until the latest export completes or --timeout passes
+func waitForExport(client *adminClient) (*exportState, error) {
+	deadline := time.Now().Add(dataTimeout)
+	lastState := ""
+	for {
+		var state exportState
+		if err := client.query("_system/cli/exports:getLatest", nil, &state); err != nil {
+			return nil, fmt.Errorf("failed to check export status: %w", err)
+		}
+
+		switch state.State {
+		case "completed":
+			return &state, nil
+		case "failed":
+			return nil, fmt.Errorf("export failed: %s", state.ErrorMessage)
+		case "requested", "in_progress":
+		default:
+			return nil, fmt.Errorf("unexpected export state %q", state.State)
+		}
+
+		if state.State != lastState {
+			dataInfo("Export %s...", strings.ReplaceAll(state.State, "_", " "))
+			lastState = state.State
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("export did not complete within %s (last state: %s)", dataTimeout, state.State)
+		}
+		time.Sleep(dataPollInterval)
+	}
+}
+
+// downloadExport streams the snapshot to a temporary file next to dest and
+// renames it into place once complete
+func downloadExport(client *adminClient, snapshotTs, dest string) (int64, error) {
+	req, err := client.newRequest("GET", "/api/export/zip/"+url.PathEscape(snapshotTs), nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := client.stream(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to download snapshot: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return 0, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	tmp := dest + ".partial"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create %s: %w", tmp, err)
+	}
+	defer os.Remove(tmp)
+
+	progress := newTransferProgress("Downloading", resp.ContentLength)
+	size, err := io.Copy(f, io.TeeReader(resp.Body, progress))
+	progress.finish()
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to download snapshot: %w", err)
+	}
+
+	if err := os.Rename(tmp, dest); err != nil {
+		return 0, fmt.Errorf("failed to write %s: %w", dest, err)
+	}
+	return size, nil
+}
+
+func runDataImport(cmd *cobra.Command, args []string) error {
+	path := args[0]
+
+	format, ok := importFormats[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return fmt.Errorf("unsupported import file %s (expected .zip, .jsonl, .json or .csv)", path)
+	}
+	if format != "zip" && dataImportTable == "" {
+		return fmt.Errorf("--table is required when importing a %s file", filepath.Ext(path))
+	}
+
+	mode := "requireEmpty"
+	switch {
+	case dataImportReplace && dataImportAppend:
+		return fmt.Errorf("--replace and --append cannot be used together")
+	case dataImportReplace:
+		mode = "replace"
+	case dataImportAppend:
+		mode = "append"
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	if mode == "replace" && !flagYes {
+		target := "every table in the snapshot"
+		if dataImportTable != "" {
+			target = "table " + dataImportTable
+		}
+		fmt.Printf("This will replace all documents in %s.\n", target)
+		fmt.Print("Type 'yes' to confirm: ")
+
+		reader := bufio.NewReader(os.Stdin)
+		input, _ := reader.ReadString('\n')
+		if strings.TrimSpace(input) != "yes" {
+			return fmt.Errorf("import cancelled")
+		}
+	}
+
+	client, err := newAdminClient()
+	if err != nil {
+		return err
+	}
+
+	importID, err := uploadImport(client, f, stat.Size(), format, mode, dataImportTable)
+	if err != nil {
+		return err
+	}
+
+	rows, err := waitForImport(client, importID)
+	if err != nil {
+		return err
+	}
+
+	output := DataImportOutput{
+		Path:        path,
+		Format:      format,
+		Mode:        mode,
+		Table:       dataImportTable,
+		ImportID:    importID,
+		RowsWritten: rows,
+	}
+	if flagJSON {
+		return printJSON(output)
+	}
+	printSuccess("Imported %d documents from %s", rows, path)
+	return nil
+}
+
+// uploadImport uploads the file in parts and registers the import
+func uploadImport(client *adminClient, r io.Reader, size int64, format, mode, table string) (string, error) {
+	var start struct {
+		UploadToken string `json:"uploadToken"`
+	}
+	if err := client.do("POST", "/api/import/start_upload", nil, &start); err != nil {
+		return "", fmt.Errorf("failed to start upload: %w", err)
+	}
+
+	progress := newTransferProgress("Uploading", size)
+	var partTokens []json.RawMessage
+	buf := make([]byte, importPartSize)
+	for partNumber := 1; ; partNumber++ {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			token, uploadErr := uploadImportPart(client, start.UploadToken, partNumber, buf[:n])
+			if uploadErr != nil {
+				progress.finish()
+				return "", uploadErr
+			}
+			partTokens = append(partTokens, token)
+			progress.Write(buf[:n])
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			progress.finish()
+			return "", fmt.Errorf("failed to read import file: %w", err)
+		}
+	}
+	progress.finish()
+
+	importFormat := map[string]string{"format": format}
+	if table != "" {
+		importFormat["tableName"] = table
+	}
+	finish := map[string]interface{}{
+		"importFormat": importFormat,
+		"mode":         mode,
+		"uploadToken":  start.UploadToken,
+		"partTokens":   partTokens,
+	}
+
+	var result struct {
+		ImportID string `json:"importId"`
+	}
+	if err := client.do("POST", "/api/import/finish_upload", finish, &result); err != nil {
+		return "", fmt.Errorf("failed to finish upload: %w", err)
+	}
+	return result.ImportID, nil
+}
+
+func uploadImportPart(client *adminClient, uploadToken string, partNumber int, data []byte) (json.RawMessage, error) {
+	query := url.Values{}
+	query.Set("uploadToken", uploadToken)
+	query.Set("partNumber", strconv.Itoa(partNumber))
+
+	req, err := client.newRequest("POST", "/api/import/upload_part?"+query.Encode(), bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	resp, err := client.stream(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload part %d: %w", partNumber, err)
+	}
+	defer resp.Body.Close()
+
+	var token json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return nil, fmt.Errorf("invalid response for part %d: %w", partNumber, err)
+	}
+	return token, nil
+}
+
+// waitForImport confirms the import once the backend has checked it and
+// polls until it completes or --timeout passes, returning the number of
+// documents written
+func waitForImport(client *adminClient, importID string) (int64, error) {
+	deadline := time.Now().Add(dataTimeout)
+	confirmed := false
+	lastMessage := ""
+	for {
+		var state importState
+		if err := client.query("_system/cli/queryImport", map[string]string{"importId": importID}, &state); err != nil {
+			return 0, fmt.Errorf("failed to check import status: %w", err)
+		}
+
+		switch state.State {
+		case "completed":
+			rows, _ := state.NumRowsWritten.Int64()
+			return rows, nil
+		case "failed":
+			return 0, fmt.Errorf("import failed: %s", state.ErrorMessage)
+		case "waiting_for_confirmation":
+			if !confirmed {
+				if state.MessageToConfirm != "" {
+					dataInfo("%s", state.MessageToConfirm)
+				}
+				if err := client.do("POST", "/api/perform_import", map[string]string{"importId": importID}, nil); err != nil {
+					return 0, fmt.Errorf("failed to start import: %w", err)
+				}
+				confirmed = true
+			}
+		case "in_progress":
+			if state.ProgressMessage != "" && state.ProgressMessage != lastMessage {
+				dataInfo("%s", state.ProgressMessage)
+				lastMessage = state.ProgressMessage
+			}
+		case "uploaded":
+		default:
+			return 0, fmt.Errorf("unexpected import state %q", state.State)
+		}
+
+		if time.Now().After(deadline) {
+			return 0, fmt.Errorf("import %s did not complete within %s (last state: %s)", importID, dataTimeout, state.State)
+		}
+		time.Sleep(dataPollInterval)
+	}
+}
+
+// dataInfo prints progress unless JSON output was requested
+func dataInfo(format string, args ...interface{}) {
+	if !flagJSON {
+		printInfo(format, args...)
+	}
+}
+
+// transferProgress reports bytes transferred to stderr at most once a second
+type transferProgress struct {
+	label   string
+	total   int64
+	done    int64
+	last    time.Time
+	enabled bool
+}
+
+func newTransferProgress(label string, total int64) *transferProgress {
+	return &transferProgress{
+		label:   label,
+		total:   total,
+		last:    time.Now(),
+		enabled: !flagQuiet && !flagJSON,
+	}
+}
+
+func (p *transferProgress) Write(b []byte) (int, error) {
+	p.done += int64(len(b))
+	if p.enabled && time.Since(p.last) >= time.Second {
+		p.print()
+		p.last = time.Now()
+	}
+	return len(b), nil
+}
+
+func (p *transferProgress) print() {
+	if p.total > 0 {
+		fmt.Fprintf(os.Stderr, "\r%s %s / %s (%d%%)", p.label, humanizeBytes(p.done), humanizeBytes(p.total), p.done*100/p.total)
+	} else {
+		fmt.Fprintf(os.Stderr, "\r%s %s", p.label, humanizeBytes(p.done))
+	}
+}
+
+func (p *transferProgress) finish() {
+	if p.enabled {
+		p.print()
+		fmt.Fprintln(os.Stderr)
+	}
+}
@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// snapshotBackend stands in for the backend's export and import endpoints
+type snapshotBackend struct {
+	mu        sync.Mutex
+	snapshot  []byte
+	polls     int
+	uploaded  [][]byte
+	finish    map[string]interface{}
+	performed bool
+	// exportValue, if set, is returned for every export status query
+	exportValue string
+}
+
+func newSnapshotBackend(t *testing.T) (*snapshotBackend, *httptest.Server) {
+	t.Helper()
+
+	b := &snapshotBackend{snapshot: bytes.Repeat([]byte("snapshot"), 1000)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		switch r.URL.Path {
+		case "/api/export/request/zip":
+			w.Write([]byte("{}"))
+		case "/api/export/zip/1700000000000000000":
+			w.Write(b.snapshot)
+		case "/api/import/start_upload":
+			w.Write([]byte(`{"uploadToken":"upload-1"}`))
+		case "/api/import/upload_part":
+			if r.URL.Query().Get("uploadToken") != "upload-1" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			data, _ := io.ReadAll(r.Body)
+			b.uploaded = append(b.uploaded, data)
+			json.NewEncoder(w).Encode("part-" + r.URL.Query().Get("partNumber"))
+		case "/api/import/finish_upload":
+			json.NewDecoder(r.Body).Decode(&b.finish)
+			w.Write([]byte(`{"importId":"import-1"}`))
+		case "/api/perform_import":
+			b.performed = true
+			w.Write([]byte("{}"))
+		case "/api/query":
+			var req struct {
+				Path string `json:"path"`
+			}
+			json.NewDecoder(r.Body).Decode(&req)
+			b.polls++
+
+			var value string
+			switch req.Path {
+			case "_system/cli/exports:getLatest":
+				value = `{"state":"in_progress"}`
+				if b.exportValue != "" {
+					value = b.exportValue
+				} else if b.polls > 1 {
+					value = `{"state":"completed","start_ts":1700000000000000000}`
+				}
+			case "_system/cli/queryImport":
+				switch {
+				case !b.performed:
+					value = `{"state":"waiting_for_confirmation","message_to_confirm":"Import 3 documents"}`
+				default:
+					value = `{"state":"completed","num_rows_written":3}`
+				}
+			}
+			w.Write([]byte(`{"status":"success","value":` + value + `}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return b, server
+}
+
+func useFastDataPolling(t *testing.T) {
+	oldInterval, oldPartSize := dataPollInterval, importPartSize
+	dataPollInterval = 10 * time.Millisecond
+	importPartSize = 3000
+	flagQuiet = true
+	t.Cleanup(func() {
+		dataPollInterval, importPartSize = oldInterval, oldPartSize
+		flagQuiet = false
+	})
+}
+
+func TestDataExport(t *testing.T) {
+	b, server := newSnapshotBackend(t)
+	useStubBackend(t, server, "test|key")
+	useFastDataPolling(t)
+
+	dataExportPath = filepath.Join(t.TempDir(), "out", "snapshot.zip")
+	defer func() { dataExportPath = "" }()
+
+	if err := runDataExport(dataExportCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(dataExportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, b.snapshot) {
+		t.Errorf("downloaded %d bytes, want %d", len(data), len(b.snapshot))
+	}
+	if _, err := os.Stat(dataExportPath + ".partial"); !os.IsNotExist(err) {
+		t.Error("partial download file left behind")
+	}
+}
+
+func TestWaitForExport_Errors(t *testing.T) {
+	b, server := newSnapshotBackend(t)
+	useStubBackend(t, server, "test|key")
+	useFastDataPolling(t)
+	oldTimeout := dataTimeout
+	dataTimeout = 50 * time.Millisecond
+	defer func() { dataTimeout = oldTimeout }()
+
+	client, err := newAdminClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		value, want string
+	}{
+		{`{"state":""}`, `unexpected export state ""`},
+		{`{"state":"paused"}`, `unexpected export state "paused"`},
+		{`{"state":"in_progress"}`, "did not complete within 50ms"},
+	}
+	for _, tt := range tests {
+		b.mu.Lock()
+		b.exportValue = tt.value
+		b.mu.Unlock()
+		if _, err := waitForExport(client); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("state %s: expected error containing %q, got %v", tt.value, tt.want, err)
+		}
+	}
+}
+
+func TestDataImport(t *testing.T) {
+	b, server := newSnapshotBackend(t)
+	useStubBackend(t, server, "test|key")
+	useFastDataPolling(t)
+
+	path := filepath.Join(t.TempDir(), "messages.jsonl")
+	content := strings.Repeat(`{"body":"hello"}`+"\n", 500)
+	os.WriteFile(path, []byte(content), 0644)
+
+	dataImportTable, dataImportAppend = "messages", true
+	defer func() { dataImportTable, dataImportAppend = "", false }()
+
+	if err := runDataImport(dataImportCmd, []string{path}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(b.uploaded) != 3 || string(bytes.Join(b.uploaded, nil)) != content {
+		t.Errorf("uploaded %d parts that do not match the file", len(b.uploaded))
+	}
+	if b.finish["mode"] != "append" || len(b.finish["partTokens"].([]interface{})) != 3 {
+		t.Errorf("unexpected finish_upload request: %v", b.finish)
+	}
+	format := b.finish["importFormat"].(map[string]interface{})
+	if format["format"] != "jsonLines" || format["tableName"] != "messages" {
+		t.Errorf("unexpected import format: %v", format)
+	}
+	if !b.performed {
+		t.Error("import was not confirmed")
+	}
+}
+
+func TestDataImport_RequiresTable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "messages.csv")
+	os.WriteFile(path, []byte("a,b\n"), 0644)
+
+	if err := runDataImport(dataImportCmd, []string{path}); err == nil || !strings.Contains(err.Error(), "--table") {
+		t.Fatalf("expected --table error, got %v", err)
+	}
+}
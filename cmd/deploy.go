@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+// DeployOutput represents JSON output for the deploy command
+type DeployOutput struct {
+	Package  string     `json:"package"`
+	Modules  int        `json:"modules"`
+	DryRun   bool       `json:"dryRun"`
+	Deployed bool       `json:"deployed"`
+	Diff     ModuleDiff `json:"diff"`
+}
+
+var (
+	deployDryRun bool
+	deployForce  bool
+)
+
+var deployCmd = &cobra.Command{
+	Use:   "deploy <path>",
+	Short: "Deploy Convex functions to the installed backend",
+	Long: `Deploy a pre-built Convex functions package to the installed backend without Node.js.
+
+The path is either a functions package directory (config.json and modules/) as
+produced by convex-bundler, or a bundle directory containing one under functions/.
+The package is pushed through the backend's admin API using /etc/convex/admin.key.
+
+The modules that would be added, modified or removed are shown before deploying.
+Use --dry-run to only show the diff.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDeploy,
+}
+
+func init() {
+	rootCmd.AddCommand(deployCmd)
+	deployCmd.Flags().BoolVar(&deployDryRun, "dry-run", false, "Show the module diff without deploying")
+	deployCmd.Flags().BoolVarP(&deployForce, "force", "f", false, "Deploy even if no modules changed")
+}
+
+func runDeploy(cmd *cobra.Command, args []string) error {
+	pkgDir := args[0]
+	if info, err := os.Stat(filepath.Join(pkgDir, "functions")); err == nil && info.IsDir() {
+		pkgDir = filepath.Join(pkgDir, "functions")
+	}
+
+	pkg, err := loadFunctionsPackage(pkgDir)
+	if err != nil {
+		return err
+	}
+
+	client, err := newAdminClient()
+	if err != nil {
+		return err
+	}
+
+	deployed, err := client.getModuleHashes()
+	if err != nil {
+		return err
+	}
+
+	output := DeployOutput{
+		Package: pkgDir,
+		Modules: len(pkg.Modules),
+		DryRun:  deployDryRun,
+		Diff:    diffModules(pkg, deployed),
+	}
+
+	if !flagJSON {
+		printModuleDiff(output.Diff)
+	}
+
+	if deployDryRun {
+		if flagJSON {
+			return printJSON(output)
+		}
+		printInfo("Dry run, nothing deployed")
+		return nil
+	}
+
+	if !output.Diff.hasChanges() && !deployForce {
+		if flagJSON {
+			return printJSON(output)
+		}
+		printSuccess("Functions are up to date")
+		return nil
+	}
+
+	if !flagJSON {
+		printInfo("Deploying %d modules...", len(pkg.Modules))
+	}
+	if err := client.pushFunctions(pkg); err != nil {
+		return err
+	}
+	output.Deployed = true
+
+	if !flagJSON {
+		printInfo("Waiting for backend to be ready...")
+	}
+	if err := waitForHealth(); err != nil {
+		return fmt.Errorf("functions deployed but backend is unhealthy: %w", err)
+	}
+
+	if flagJSON {
+		return printJSON(output)
+	}
+	printSuccess("Deployed %d modules", len(pkg.Modules))
+	return nil
+}
+
+func printModuleDiff(diff ModuleDiff) {
+	for _, path := range diff.Added {
+		fmt.Printf("  + %s\n", path)
+	}
+	for _, path := range diff.Modified {
+		fmt.Printf("  ~ %s\n", path)
+	}
+	for _, path := range diff.Removed {
+		fmt.Printf("  - %s\n", path)
+	}
+	fmt.Printf("%d added, %d modified, %d removed, %d unchanged\n",
+		len(diff.Added), len(diff.Modified), len(diff.Removed), diff.Unchanged)
+}
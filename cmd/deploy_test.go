@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// stubBackend is a minimal admin API that records pushed modules and
+// environment variables and runs a few canned functions
+type stubBackend struct {
+	mu      sync.Mutex
+	modules map[string]string
+	pushes  int
+	env     map[string]string
+}
+
+func newStubBackend(t *testing.T, adminKey string) (*stubBackend, *httptest.Server) {
+	t.Helper()
+
+	stub := &stubBackend{modules: map[string]string{}, env: map[string]string{}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/version" {
+			w.Write([]byte("unknown"))
+			return
+		}
+		if r.Header.Get("Authorization") != "Convex "+adminKey {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"code":"BadAdminKey","message":"The provided admin key was invalid"}`))
+			return
+		}
+
+		stub.mu.Lock()
+		defer stub.mu.Unlock()
+
+		switch r.URL.Path {
+		case "/api/get_config_hashes":
+			resp := configHashesResponse{ModuleHashes: []moduleHash{}}
+			for path, source := range stub.modules {
+				resp.ModuleHashes = append(resp.ModuleHashes, moduleHash{Path: path, Hash: hashModuleSource(source)})
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/api/push_config":
+			var req pushConfigRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			stub.modules = map[string]string{}
+			for _, m := range req.Modules {
+				stub.modules[m.Path] = m.Source
+			}
+			stub.pushes++
+			w.Write([]byte("{}"))
+		case "/api/v1/list_environment_variables":
+			json.NewEncoder(w).Encode(map[string]interface{}{"environmentVariables": stub.env})
+		case "/api/v1/update_environment_variables":
+			var req struct {
+				Changes []envChange `json:"changes"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			for _, c := range req.Changes {
+				if c.Value == nil {
+					delete(stub.env, c.Name)
+				} else {
+					stub.env[c.Name] = *c.Value
+				}
+			}
+			w.Write([]byte("{}"))
+		case "/api/function":
+			var req struct {
+				Path string                 `json:"path"`
+				Args map[string]interface{} `json:"args"`
+			}
+			json.NewDecoder(r.Body).Decode(&req)
+			switch req.Path {
+			case "admin:echo":
+				json.NewEncoder(w).Encode(map[string]interface{}{"status": "success", "value": req.Args, "logLines": []string{"[LOG] echo"}})
+			case "admin:fail":
+				w.Write([]byte(`{"status":"error","errorMessage":"Uncaught Error: boom","logLines":[]}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"code":"FunctionNotFound","message":"Could not find function"}`))
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return stub, server
+}
+
+// useStubBackend points the admin client and health checks at the stub
+func useStubBackend(t *testing.T, server *httptest.Server, adminKey string) {
+	t.Helper()
+
+	keyPath := filepath.Join(t.TempDir(), "admin.key")
+	if err := os.WriteFile(keyPath, []byte(adminKey+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	oldKeyPath := adminKeyPath
+	adminKeyPath = keyPath
+	t.Cleanup(func() { adminKeyPath = oldKeyPath })
+	t.Setenv("CONVEX_BACKEND_URL", server.URL)
+}
+
+func TestRunDeploy(t *testing.T) {
+	stub, server := newStubBackend(t, "test|key")
+	useStubBackend(t, server, "test|key")
+	stub.modules["old.js"] = "export const old = 1;"
+
+	bundle := t.TempDir()
+	writeTestFunctions(t, filepath.Join(bundle, "functions"), map[string]string{
+		"messages.js": "export const list = 1;",
+	})
+
+	flagQuiet = true
+	defer func() { flagQuiet = false; deployDryRun = false }()
+
+	// Dry run leaves the backend untouched
+	deployDryRun = true
+	if err := runDeploy(deployCmd, []string{bundle}); err != nil {
+		t.Fatal(err)
+	}
+	if stub.pushes != 0 {
+		t.Fatalf("dry run pushed %d times", stub.pushes)
+	}
+
+	deployDryRun = false
+	if err := runDeploy(deployCmd, []string{bundle}); err != nil {
+		t.Fatal(err)
+	}
+	if stub.pushes != 1 || stub.modules["messages.js"] == "" || stub.modules["old.js"] != "" {
+		t.Fatalf("unexpected deployed modules after push: %v", stub.modules)
+	}
+
+	// Nothing changed, so the second deploy is skipped
+	if err := runDeploy(deployCmd, []string{bundle}); err != nil {
+		t.Fatal(err)
+	}
+	if stub.pushes != 1 {
+		t.Errorf("expected unchanged deploy to be skipped, got %d pushes", stub.pushes)
+	}
+}
+
+func TestRunDeploy_BadAdminKey(t *testing.T) {
+	_, server := newStubBackend(t, "test|key")
+	useStubBackend(t, server, "test|wrong")
+
+	dir := t.TempDir()
+	writeTestFunctions(t, dir, map[string]string{"messages.js": "export const list = 1;"})
+
+	if err := runDeploy(deployCmd, []string{dir}); err == nil {
+		t.Fatal("expected error with wrong admin key")
+	}
+}
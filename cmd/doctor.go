@@ -0,0 +1,523 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Doctor finding severities
+const (
+	severityOK      = "ok"
+	severityInfo    = "info"
+	severityWarning = "warning"
+	severityError   = "error"
+)
+
+// orphanedBundleAge is how old an embedded bundle extraction directory
+// without a pid file must be before doctor treats it as left behind by an
+// interrupted install
+const orphanedBundleAge = time.Hour
+
+// DoctorFinding is the result of one diagnostic check
+type DoctorFinding struct {
+	Check      string `json:"check"`
+	Severity   string `json:"severity"`
+	Message    string `json:"message"`
+	Suggestion string `json:"suggestion,omitempty"`
+	Fixable    bool   `json:"fixable"`
+	Fixed      bool   `json:"fixed"`
+	FixError   string `json:"fixError,omitempty"`
+
+	fix func() error
+}
+
+// DoctorOutput represents JSON output for the doctor command
+type DoctorOutput struct {
+	Findings []DoctorFinding `json:"findings"`
+	Errors   int             `json:"errors"`
+	Warnings int             `json:"warnings"`
+	Fixed    int             `json:"fixed"`
+}
+
+// pathSpec is a path install creates, with the mode it should have
+type pathSpec struct {
+	path string
+	dir  bool
+	mode os.FileMode
+	// secret files must not be readable by other users
+	secret bool
+}
+
+var installedPaths = []pathSpec{
+	{path: "/var/lib/convex", dir: true, mode: 0755},
+	{path: "/var/lib/convex/data", dir: true, mode: 0755},
+	{path: "/var/lib/convex/data/storage", dir: true, mode: 0755},
+	{path: "/var/lib/convex/backups", dir: true, mode: 0755},
+	{path: "/etc/convex", dir: true, mode: 0755},
+	{path: "/usr/local/bin/convex-backend", mode: 0755},
+	{path: "/var/lib/convex/data/convex.db", mode: 0644},
+	{path: "/var/lib/convex/manifest.json", mode: 0644},
+	{path: "/etc/convex/convex.env", mode: 0644},
+	{path: "/etc/convex/admin.key", mode: 0600, secret: true},
+	{path: "/etc/convex/instance.secret", mode: 0600, secret: true},
+	{path: "/etc/systemd/system/convex-backend.service", mode: 0644},
+}
+
+var doctorFix bool
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose common installation problems",
+	Long: `Run a battery of checks against the installation and print findings with
+their severity and a suggested fix:
+
+  - required paths exist with the modes install gives them
+  - the systemd unit matches what install would render for the credentials
+  - the credential files are 0600 and well-formed
+  - port 3210 is not held by a process other than the backend
+  - the system clock is synchronized
+  - disk space and inodes on the data volume
+  - SQLite database integrity
+  - embedded bundle extraction directories left behind in the temp directory
+
+With --fix, problems that can be repaired safely (modes, missing directories,
+the unit file, time sync, leftover temp directories) are fixed. Exits non-zero
+if any error remains.`,
+	Args: cobra.NoArgs,
+	RunE: runDoctor,
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+	doctorCmd.Flags().BoolVar(&doctorFix, "fix", false, "Repair the problems that can be fixed automatically")
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	if doctorFix {
+		if err := checkRoot(); err != nil {
+			return err
+		}
+	}
+
+	output := runDoctorChecks(doctorFix)
+
+	if flagJSON {
+		if err := printJSON(output); err != nil {
+			return err
+		}
+	} else {
+		printDoctorFindings(output)
+	}
+
+	if output.Errors > 0 {
+		return fmt.Errorf("doctor found %d error(s)", output.Errors)
+	}
+	return nil
+}
+
+// runDoctorChecks runs every check and, if fix is set, repairs what it can
+func runDoctorChecks(fix bool) DoctorOutput {
+	var findings []DoctorFinding
+	findings = append(findings, checkInstalledPaths(installedPaths)...)
+	findings = append(findings, checkCredentialFiles()...)
+	findings = append(findings, checkUnitFile("/etc/systemd/system/convex-backend.service"))
+	findings = append(findings, checkBackendPort(3210))
+	findings = append(findings, checkTimeSync())
+	findings = append(findings, checkDiskHeadroom("/var/lib/convex/data")...)
+	findings = append(findings, checkDatabaseIntegrity("/var/lib/convex/data/convex.db"))
+	findings = append(findings, checkOrphanedBundleDirs(os.TempDir(), time.Now()))
+
+	output := DoctorOutput{Findings: findings}
+	for i := range output.Findings {
+		f := &output.Findings[i]
+		f.Fixable = f.fix != nil && f.Severity != severityOK
+
+		if fix && f.Fixable {
+			if err := f.fix(); err != nil {
+				f.FixError = err.Error()
+			} else {
+				f.Fixed = true
+				output.Fixed++
+				continue
+			}
+		}
+
+		switch f.Severity {
+		case severityError:
+			output.Errors++
+		case severityWarning:
+			output.Warnings++
+		}
+	}
+	return output
+}
+
+func printDoctorFindings(output DoctorOutput) {
+	labels := map[string]string{
+		severityOK:      "  ok  ",
+		severityInfo:    " info ",
+		severityWarning: " warn ",
+		severityError:   "ERROR ",
+	}
+
+	for _, f := range output.Findings {
+		label := labels[f.Severity]
+		if f.Fixed {
+			label = "FIXED "
+		}
+		fmt.Printf("[%s] %-12s %s\n", label, f.Check, f.Message)
+		if f.FixError != "" {
+			fmt.Printf("%22sfix failed: %s\n", "", f.FixError)
+		}
+		if f.Suggestion != "" && !f.Fixed && f.Severity != severityOK {
+			fmt.Printf("%22s→ %s\n", "", f.Suggestion)
+		}
+	}
+
+	fmt.Println()
+	fmt.Printf("%d error(s), %d warning(s)", output.Errors, output.Warnings)
+	if output.Fixed > 0 {
+		fmt.Printf(", %d fixed", output.Fixed)
+	}
+	fmt.Println()
+
+	var fixable int
+	for _, f := range output.Findings {
+		if f.Fixable && !f.Fixed {
+			fixable++
+		}
+	}
+	if fixable > 0 {
+		fmt.Printf("Run 'convex-backend-ops doctor --fix' to repair %d of them.\n", fixable)
+	}
+}
+
+// checkInstalledPaths verifies that each path exists with the expected type and mode
+func checkInstalledPaths(specs []pathSpec) []DoctorFinding {
+	var findings []DoctorFinding
+	for _, spec := range specs {
+		spec := spec
+		f := DoctorFinding{Check: "paths"}
+
+		info, err := os.Stat(spec.path)
+		switch {
+		case os.IsNotExist(err) && spec.dir:
+			f.Severity = severityError
+			f.Message = fmt.Sprintf("%s is missing", spec.path)
+			f.Suggestion = "create the directory"
+			f.fix = func() error { return os.MkdirAll(spec.path, spec.mode) }
+		case os.IsNotExist(err):
+			f.Severity = severityError
+			f.Message = fmt.Sprintf("%s is missing", spec.path)
+			f.Suggestion = "restore it with 'rollback' or reinstall"
+		case err != nil:
+			f.Severity = severityError
+			f.Message = fmt.Sprintf("cannot read %s: %v", spec.path, err)
+		case info.IsDir() != spec.dir:
+			f.Severity = severityError
+			f.Message = fmt.Sprintf("%s has the wrong type", spec.path)
+			f.Suggestion = "move it aside and reinstall"
+		case info.Mode().Perm() != spec.mode:
+			f.Severity = severityWarning
+			if spec.secret && info.Mode().Perm()&0077 != 0 {
+				f.Severity = severityError
+			}
+			f.Message = fmt.Sprintf("%s has mode %04o, expected %04o", spec.path, info.Mode().Perm(), spec.mode)
+			f.Suggestion = fmt.Sprintf("chmod %04o %s", spec.mode, spec.path)
+			f.fix = func() error { return os.Chmod(spec.path, spec.mode) }
+		default:
+			continue
+		}
+		findings = append(findings, f)
+	}
+
+	if len(findings) == 0 {
+		findings = append(findings, DoctorFinding{Check: "paths", Severity: severityOK, Message: "all installed paths present with expected modes"})
+	}
+	return findings
+}
+
+// checkCredentialFiles verifies the installed credentials are well-formed
+func checkCredentialFiles() []DoctorFinding {
+	creds, err := readInstalledCredentials()
+	if err != nil {
+		return []DoctorFinding{{Check: "credentials", Severity: severityError, Message: err.Error(),
+			Suggestion: "restore them with 'credentials revert' or reinstall"}}
+	}
+
+	trimmed := &Credentials{AdminKey: strings.TrimSpace(creds.AdminKey), InstanceSecret: strings.TrimSpace(creds.InstanceSecret)}
+	if err := trimmed.validate(); err != nil {
+		return []DoctorFinding{{Check: "credentials", Severity: severityError, Message: "installed credentials are invalid: " + err.Error(),
+			Suggestion: "run 'credentials rotate' to issue new ones"}}
+	}
+	return []DoctorFinding{{Check: "credentials", Severity: severityOK,
+		Message: fmt.Sprintf("admin key and instance secret for instance %q are well-formed", instanceNameFromAdminKey(trimmed.AdminKey))}}
+}
+
+// checkUnitFile compares the unit with what install would render for the
+// installed credentials
+func checkUnitFile(unitPath string) DoctorFinding {
+	f := DoctorFinding{Check: "unit"}
+
+	creds, err := readInstalledCredentials()
+	if err != nil {
+		f.Severity = severityWarning
+		f.Message = "cannot compare the unit without the installed credentials"
+		return f
+	}
+
+	data, err := os.ReadFile(unitPath)
+	if err != nil {
+		f.Severity = severityError
+		f.Message = fmt.Sprintf("cannot read %s: %v", unitPath, err)
+	} else if string(data) != renderSystemdUnit(creds) {
+		f.Severity = severityWarning
+		f.Message = fmt.Sprintf("%s differs from what install would write (hand edits, or out of sync with the credentials)", unitPath)
+	} else {
+		f.Severity = severityOK
+		f.Message = "unit file matches the installed credentials"
+		return f
+	}
+
+	f.Suggestion = "rewrite the unit and reload systemd, then restart the backend"
+	f.fix = installSystemdService
+	return f
+}
+
+// checkBackendPort reports a process other than the backend holding the port
+func checkBackendPort(port int) DoctorFinding {
+	f := DoctorFinding{Check: "port"}
+	owner := portOwner(port)
+	mainPID, _ := strconv.Atoi(backendService.property("MainPID"))
+
+	switch {
+	case owner == 0 && mainPID == 0:
+		f.Severity = severityOK
+		f.Message = fmt.Sprintf("port %d is free", port)
+	case owner == 0:
+		f.Severity = severityWarning
+		f.Message = fmt.Sprintf("the backend (pid %d) is not listening on port %d", mainPID, port)
+		f.Suggestion = "check 'convex-backend-ops logs'"
+	case owner == mainPID:
+		f.Severity = severityOK
+		f.Message = fmt.Sprintf("port %d is held by the backend (pid %d)", port, owner)
+	default:
+		f.Severity = severityError
+		f.Message = fmt.Sprintf("port %d is held by another process: pid %d (%s)", port, owner, processName(owner))
+		f.Suggestion = fmt.Sprintf("stop pid %d or move it to another port", owner)
+	}
+	return f
+}
+
+// portOwner returns the pid of the process listening on a TCP port, or 0
+func portOwner(port int) int {
+	inodes := make(map[string]bool)
+	for _, table := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		data, err := os.ReadFile(table)
+		if err != nil {
+			continue
+		}
+		for inode, p := range parseProcNetListeners(string(data)) {
+			if p == port {
+				inodes[inode] = true
+			}
+		}
+	}
+	if len(inodes) == 0 {
+		return 0
+	}
+
+	procs, _ := filepath.Glob("/proc/[0-9]*/fd/*")
+	for _, fd := range procs {
+		target, err := os.Readlink(fd)
+		if err != nil || !strings.HasPrefix(target, "socket:[") {
+			continue
+		}
+		if inodes[strings.TrimSuffix(strings.TrimPrefix(target, "socket:["), "]")] {
+			pid, _ := strconv.Atoi(strings.Split(fd, "/")[2])
+			return pid
+		}
+	}
+	return 0
+}
+
+// bundleDirOwner returns the pid recorded in an extraction directory
+func bundleDirOwner(dir string) (int, bool) {
+	data, err := os.ReadFile(filepath.Join(dir, bundlePidFile))
+	if err != nil {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, false
+	}
+	return pid, true
+}
+
+func processRunning(pid int) bool {
+	_, err := os.Stat(fmt.Sprintf("/proc/%d", pid))
+	return err == nil
+}
+
+func processName(pid int) string {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/comm", pid))
+	if err != nil {
+		return "unknown"
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// checkTimeSync checks that systemd reports the clock as synchronized
+func checkTimeSync() DoctorFinding {
+	f := DoctorFinding{Check: "time"}
+	output, err := exec.Command("timedatectl", "show", "--property", "NTPSynchronized", "--value").Output()
+	switch {
+	case err != nil:
+		f.Severity = severityInfo
+		f.Message = "cannot determine time sync status (timedatectl unavailable)"
+	case strings.TrimSpace(string(output)) == "yes":
+		f.Severity = severityOK
+		f.Message = "system clock is synchronized"
+	default:
+		f.Severity = severityWarning
+		f.Message = "system clock is not synchronized; timestamps and admin keys may be off"
+		f.Suggestion = "timedatectl set-ntp true"
+		f.fix = func() error { return exec.Command("timedatectl", "set-ntp", "true").Run() }
+	}
+	return f
+}
+
+// checkDiskHeadroom checks free space and inodes on the data volume
+func checkDiskHeadroom(dataDir string) []DoctorFinding {
+	s := getStorageStatus(dataDir)
+	if s.DiskTotalBytes == 0 {
+		return []DoctorFinding{{Check: "disk", Severity: severityWarning, Message: fmt.Sprintf("cannot read disk usage of %s", dataDir)}}
+	}
+
+	space := DoctorFinding{Check: "disk", Severity: severityOK,
+		Message: fmt.Sprintf("%s free on the data volume (%d%%)", humanizeBytes(s.DiskFreeBytes), s.DiskFreePercentage)}
+	// An upgrade backup copies the whole data directory
+	if needed := s.DatabaseBytes + s.StorageBytes; s.DiskFreeBytes < needed {
+		space.Severity = severityError
+		space.Suggestion = fmt.Sprintf("free at least %s so the next upgrade can back up the data", humanizeBytes(needed))
+	} else if s.DiskFreePercentage < 10 {
+		space.Severity = severityWarning
+		space.Suggestion = "free disk space or prune old backups"
+	}
+
+	findings := []DoctorFinding{space}
+	if s.DiskTotalInodes > 0 {
+		pct := int(s.DiskFreeInodes * 100 / s.DiskTotalInodes)
+		inodes := DoctorFinding{Check: "disk", Severity: severityOK, Message: fmt.Sprintf("%d%% of inodes free", pct)}
+		if pct < 10 {
+			inodes.Severity = severityWarning
+			inodes.Suggestion = "remove small files; file storage uses one file per object"
+		}
+		findings = append(findings, inodes)
+	}
+	return findings
+}
+
+// checkDatabaseIntegrity checks the SQLite header and, if the sqlite3 CLI is
+// available, runs PRAGMA quick_check
+func checkDatabaseIntegrity(dbPath string) DoctorFinding {
+	f := DoctorFinding{Check: "database", Severity: severityError}
+
+	if err := checkSQLiteHeader(dbPath); err != nil {
+		f.Message = err.Error()
+		f.Suggestion = "restore the data with 'rollback' or 'data import'"
+		return f
+	}
+
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		f.Severity = severityInfo
+		f.Message = "database header is valid (install sqlite3 for a full integrity check)"
+		return f
+	}
+
+	output, err := exec.Command("sqlite3", "-readonly", dbPath, "PRAGMA quick_check;").CombinedOutput()
+	result := strings.TrimSpace(string(output))
+	if err != nil || result != "ok" {
+		f.Message = fmt.Sprintf("integrity check failed: %s", result)
+		f.Suggestion = "restore the data with 'rollback' or 'data import'"
+		return f
+	}
+
+	f.Severity = severityOK
+	f.Message = "database integrity check passed"
+	return f
+}
+
+// checkSQLiteHeader validates the SQLite file header and that the file size
+// is a whole number of pages
+func checkSQLiteHeader(dbPath string) error {
+	f, err := os.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("cannot read database: %v", err)
+	}
+	defer f.Close()
+
+	// Only the 100-byte header is read; the database may be many gigabytes
+	pageSize, _, err := readSQLiteHeader(f)
+	if err != nil {
+		return fmt.Errorf("%s is not an SQLite database: %v", dbPath, err)
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("cannot stat database: %v", err)
+	}
+	if stat.Size()%int64(pageSize) != 0 {
+		return fmt.Errorf("database size %d is not a multiple of the page size %d (truncated?)", stat.Size(), pageSize)
+	}
+	return nil
+}
+
+// checkOrphanedBundleDirs finds embedded bundle extraction directories that
+// an interrupted install or upgrade did not clean up. Directories whose pid
+// file names a running process are in use, however old.
+func checkOrphanedBundleDirs(tempDir string, now time.Time) DoctorFinding {
+	f := DoctorFinding{Check: "tempdirs", Severity: severityOK, Message: "no leftover bundle extraction directories"}
+
+	matches, _ := filepath.Glob(filepath.Join(tempDir, "convex-bundle-*"))
+	var orphaned []string
+	var size int64
+	for _, dir := range matches {
+		info, err := os.Stat(dir)
+		if err != nil || !info.IsDir() {
+			continue
+		}
+		if pid, ok := bundleDirOwner(dir); ok {
+			if processRunning(pid) {
+				continue
+			}
+		} else if now.Sub(info.ModTime()) < orphanedBundleAge {
+			// No pid file yet, or written by an older version
+			continue
+		}
+		orphaned = append(orphaned, dir)
+		size += getDirSize(dir)
+	}
+	if len(orphaned) == 0 {
+		return f
+	}
+
+	f.Severity = severityWarning
+	f.Message = fmt.Sprintf("%d leftover bundle extraction director(ies) using %s: %s", len(orphaned), humanizeBytes(size), strings.Join(orphaned, ", "))
+	f.Suggestion = "remove them"
+	f.fix = func() error {
+		for _, dir := range orphaned {
+			if err := os.RemoveAll(dir); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return f
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckInstalledPaths(t *testing.T) {
+	dir := t.TempDir()
+	os.Mkdir(filepath.Join(dir, "data"), 0700)
+	os.WriteFile(filepath.Join(dir, "admin.key"), []byte("key"), 0644)
+	os.WriteFile(filepath.Join(dir, "convex.env"), []byte("A=1"), 0644)
+
+	specs := []pathSpec{
+		{path: filepath.Join(dir, "data"), dir: true, mode: 0755},
+		{path: filepath.Join(dir, "backups"), dir: true, mode: 0755},
+		{path: filepath.Join(dir, "admin.key"), mode: 0600, secret: true},
+		{path: filepath.Join(dir, "convex.env"), mode: 0644},
+		{path: filepath.Join(dir, "convex-backend"), mode: 0755},
+	}
+
+	findings := checkInstalledPaths(specs)
+	if len(findings) != 4 {
+		t.Fatalf("expected 4 findings, got %+v", findings)
+	}
+
+	want := []struct {
+		severity string
+		fixable  bool
+	}{
+		{severityWarning, true}, // data has mode 0700
+		{severityError, true},   // backups is missing
+		{severityError, true},   // admin.key is world-readable
+		{severityError, false},  // binary is missing
+	}
+	for i, w := range want {
+		if findings[i].Severity != w.severity || (findings[i].fix != nil) != w.fixable {
+			t.Errorf("finding %d = %s (fixable %v), want %s (fixable %v): %s",
+				i, findings[i].Severity, findings[i].fix != nil, w.severity, w.fixable, findings[i].Message)
+		}
+	}
+
+	for _, f := range findings {
+		if f.fix != nil {
+			if err := f.fix(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if remaining := checkInstalledPaths(specs[:4]); len(remaining) != 1 || remaining[0].Severity != severityOK {
+		t.Errorf("expected fixes to resolve the findings, got %+v", remaining)
+	}
+}
+
+func writeTestSQLite(t *testing.T, path string, pageSize, pages int) {
+	t.Helper()
+	data := make([]byte, pageSize*pages)
+	copy(data, "SQLite format 3\x00")
+	binary.BigEndian.PutUint16(data[16:18], uint16(pageSize))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckSQLiteHeader(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "valid.db")
+	writeTestSQLite(t, valid, 4096, 3)
+	if err := checkSQLiteHeader(valid); err != nil {
+		t.Errorf("expected a valid database, got %v", err)
+	}
+
+	truncated := filepath.Join(dir, "truncated.db")
+	writeTestSQLite(t, truncated, 4096, 3)
+	os.Truncate(truncated, 4096*2+100)
+	if err := checkSQLiteHeader(truncated); err == nil || !strings.Contains(err.Error(), "truncated") {
+		t.Errorf("expected a truncation error, got %v", err)
+	}
+
+	garbage := filepath.Join(dir, "garbage.db")
+	os.WriteFile(garbage, make([]byte, 4096), 0644)
+	if err := checkSQLiteHeader(garbage); err == nil {
+		t.Error("expected an error for a non-SQLite file")
+	}
+}
+
+func TestCheckOrphanedBundleDirs(t *testing.T) {
+	tmp := t.TempDir()
+	old := filepath.Join(tmp, "convex-bundle-123")
+	fresh := filepath.Join(tmp, "convex-bundle-456")
+	other := filepath.Join(tmp, "something-else")
+	for _, dir := range []string{old, fresh, other} {
+		os.MkdirAll(dir, 0755)
+	}
+	os.WriteFile(filepath.Join(old, "backend"), make([]byte, 1000), 0755)
+
+	now := time.Now()
+	twoHoursAgo := now.Add(-2 * time.Hour)
+	os.Chtimes(old, twoHoursAgo, twoHoursAgo)
+	os.Chtimes(other, twoHoursAgo, twoHoursAgo)
+
+	f := checkOrphanedBundleDirs(tmp, now)
+	if f.Severity != severityWarning || !strings.Contains(f.Message, old) || strings.Contains(f.Message, fresh) {
+		t.Fatalf("unexpected finding: %+v", f)
+	}
+
+	if err := f.fix(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Error("expected the old directory to be removed")
+	}
+	if _, err := os.Stat(fresh); err != nil {
+		t.Error("expected a recent directory to be kept, it may belong to a running install")
+	}
+}
+
+func TestCheckOrphanedBundleDirs_PidFile(t *testing.T) {
+	tmp := t.TempDir()
+	running := filepath.Join(tmp, "convex-bundle-running")
+	exited := filepath.Join(tmp, "convex-bundle-exited")
+	for _, dir := range []string{running, exited} {
+		os.MkdirAll(dir, 0755)
+	}
+	// A soaking upgrade keeps its directory well past orphanedBundleAge
+	os.WriteFile(filepath.Join(running, bundlePidFile), []byte(fmt.Sprintf("%d\n", os.Getpid())), 0644)
+	os.WriteFile(filepath.Join(exited, bundlePidFile), []byte("999999999\n"), 0644)
+
+	now := time.Now()
+	f := checkOrphanedBundleDirs(tmp, now.Add(3*time.Hour))
+	if strings.Contains(f.Message, running) {
+		t.Errorf("expected a directory held by a running process to be kept: %+v", f)
+	}
+
+	// A dead owner makes the directory orphaned even when it is recent
+	f = checkOrphanedBundleDirs(tmp, now)
+	if f.Severity != severityWarning || !strings.Contains(f.Message, exited) {
+		t.Errorf("expected the exited owner's directory to be reported: %+v", f)
+	}
+}
+
+func TestRenderSystemdUnit(t *testing.T) {
+	unit := renderSystemdUnit(&Credentials{AdminKey: "my-app|01abcd", InstanceSecret: "4361"})
+	if !strings.Contains(unit, "--instance-name my-app --instance-secret 4361 ") {
+		t.Errorf("unexpected unit:\n%s", unit)
+	}
+}
@@ -0,0 +1,418 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// EnvVar is a deployment environment variable
+type EnvVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// EnvListOutput represents JSON output for env list
+type EnvListOutput struct {
+	EnvironmentVariables []EnvVar `json:"environmentVariables"`
+}
+
+// EnvDiffEntry describes a single change to the deployment's variables
+type EnvDiffEntry struct {
+	Name   string `json:"name"`
+	Action string `json:"action"`
+}
+
+// EnvChangeOutput represents JSON output for env set/unset/import
+type EnvChangeOutput struct {
+	Changes []EnvDiffEntry `json:"changes"`
+	DryRun  bool           `json:"dryRun"`
+	Applied bool           `json:"applied"`
+}
+
+// Diff actions for environment variables
+const (
+	envActionAdd    = "add"
+	envActionChange = "change"
+	envActionRemove = "remove"
+)
+
+// envChange is a single entry of POST /api/v1/update_environment_variables.
+// A change without a value removes the variable.
+type envChange struct {
+	Name  string  `json:"name"`
+	Value *string `json:"value,omitempty"`
+}
+
+// envVarName matches the variable names accepted by the backend
+var envVarName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{0,39}$`)
+
+var (
+	envImportPrune  bool
+	envImportDryRun bool
+)
+
+var envCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Manage deployment environment variables",
+	Long: `Manage the environment variables Convex functions read with process.env.
+
+These are stored in the backend and set through its admin API using
+/etc/convex/admin.key. They are separate from /etc/convex/convex.env, which
+configures the backend process itself.`,
+}
+
+var envListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List environment variables",
+	Args:  cobra.NoArgs,
+	RunE:  runEnvList,
+}
+
+var envGetCmd = &cobra.Command{
+	Use:   "get <name>",
+	Short: "Print the value of an environment variable",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runEnvGet,
+}
+
+var envSetCmd = &cobra.Command{
+	Use:   "set <name> <value>",
+	Short: "Set an environment variable",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runEnvSet,
+}
+
+var envUnsetCmd = &cobra.Command{
+	Use:   "unset <name>",
+	Short: "Remove an environment variable",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runEnvUnset,
+}
+
+var envImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Set environment variables from a dotenv file",
+	Long: `Set environment variables from a dotenv file (NAME=value per line).
+
+Only variables that differ are changed. With --prune, variables that are not in
+the file are removed. The changes are shown before they are applied; use
+--dry-run to only show them.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runEnvImport,
+}
+
+func init() {
+	rootCmd.AddCommand(envCmd)
+	envCmd.AddCommand(envListCmd)
+	envCmd.AddCommand(envGetCmd)
+	envCmd.AddCommand(envSetCmd)
+	envCmd.AddCommand(envUnsetCmd)
+	envCmd.AddCommand(envImportCmd)
+	envImportCmd.Flags().BoolVar(&envImportPrune, "prune", false, "Remove variables that are not in the file")
+	envImportCmd.Flags().BoolVar(&envImportDryRun, "dry-run", false, "Show the changes without applying them")
+}
+
+func runEnvList(cmd *cobra.Command, args []string) error {
+	client, err := newAdminClient()
+	if err != nil {
+		return err
+	}
+
+	vars, err := client.listEnvVars()
+	if err != nil {
+		return err
+	}
+
+	output := EnvListOutput{EnvironmentVariables: []EnvVar{}}
+	for _, name := range sortedKeys(vars) {
+		output.EnvironmentVariables = append(output.EnvironmentVariables, EnvVar{Name: name, Value: vars[name]})
+	}
+
+	if flagJSON {
+		return printJSON(output)
+	}
+
+	if len(output.EnvironmentVariables) == 0 {
+		fmt.Println("No environment variables set.")
+		return nil
+	}
+	for _, v := range output.EnvironmentVariables {
+		fmt.Printf("%s=%s\n", v.Name, v.Value)
+	}
+	return nil
+}
+
+func runEnvGet(cmd *cobra.Command, args []string) error {
+	client, err := newAdminClient()
+	if err != nil {
+		return err
+	}
+
+	vars, err := client.listEnvVars()
+	if err != nil {
+		return err
+	}
+
+	value, ok := vars[args[0]]
+	if !ok {
+		return fmt.Errorf("environment variable %s is not set", args[0])
+	}
+
+	if flagJSON {
+		return printJSON(EnvVar{Name: args[0], Value: value})
+	}
+	fmt.Println(value)
+	return nil
+}
+
+func runEnvSet(cmd *cobra.Command, args []string) error {
+	if err := validateEnvName(args[0]); err != nil {
+		return err
+	}
+	return applyEnvChanges(map[string]string{args[0]: args[1]}, nil, false, false)
+}
+
+func runEnvUnset(cmd *cobra.Command, args []string) error {
+	return applyEnvChanges(nil, []string{args[0]}, false, false)
+}
+
+func runEnvImport(cmd *cobra.Command, args []string) error {
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", args[0], err)
+	}
+
+	vars, err := parseDotenv(data)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", args[0], err)
+	}
+	for name := range vars {
+		if err := validateEnvName(name); err != nil {
+			return fmt.Errorf("invalid %s: %w", args[0], err)
+		}
+	}
+
+	return applyEnvChanges(vars, nil, envImportPrune, envImportDryRun)
+}
+
+// applyEnvChanges sets the given variables and removes the named ones. With
+// prune, every variable that is not being set is removed.
+func applyEnvChanges(set map[string]string, remove []string, prune, dryRun bool) error {
+	client, err := newAdminClient()
+	if err != nil {
+		return err
+	}
+
+	current, err := client.listEnvVars()
+	if err != nil {
+		return err
+	}
+
+	desired := make(map[string]string, len(current))
+	if !prune {
+		for name, value := range current {
+			desired[name] = value
+		}
+	}
+	for _, name := range remove {
+		if _, ok := current[name]; !ok {
+			return fmt.Errorf("environment variable %s is not set", name)
+		}
+		delete(desired, name)
+	}
+	for name, value := range set {
+		desired[name] = value
+	}
+
+	changes, diff := diffEnv(current, desired)
+	output := EnvChangeOutput{Changes: diff, DryRun: dryRun}
+
+	if !flagJSON {
+		printEnvDiff(diff)
+	}
+
+	if len(changes) > 0 && !dryRun {
+		if err := client.updateEnvVars(changes); err != nil {
+			return err
+		}
+		output.Applied = true
+	}
+
+	if flagJSON {
+		return printJSON(output)
+	}
+	if dryRun {
+		printInfo("Dry run, no changes applied")
+	} else if output.Applied {
+		printSuccess("Updated %d environment variable(s)", len(changes))
+	}
+	return nil
+}
+
+// diffEnv returns the API changes and the diff needed to go from current to desired
+func diffEnv(current, desired map[string]string) ([]envChange, []EnvDiffEntry) {
+	changes := []envChange{}
+	diff := []EnvDiffEntry{}
+
+	for _, name := range sortedKeys(desired) {
+		value := desired[name]
+		old, ok := current[name]
+		if ok && old == value {
+			continue
+		}
+		action := envActionChange
+		if !ok {
+			action = envActionAdd
+		}
+		changes = append(changes, envChange{Name: name, Value: &value})
+		diff = append(diff, EnvDiffEntry{Name: name, Action: action})
+	}
+
+	for _, name := range sortedKeys(current) {
+		if _, ok := desired[name]; !ok {
+			changes = append(changes, envChange{Name: name})
+			diff = append(diff, EnvDiffEntry{Name: name, Action: envActionRemove})
+		}
+	}
+
+	return changes, diff
+}
+
+// printEnvDiff shows changed variable names; values are not printed since
+// they often hold secrets
+func printEnvDiff(diff []EnvDiffEntry) {
+	if len(diff) == 0 {
+		fmt.Println("No changes.")
+		return
+	}
+
+	symbols := map[string]string{envActionAdd: "+", envActionChange: "~", envActionRemove: "-"}
+	for _, entry := range diff {
+		fmt.Printf("  %s %s\n", symbols[entry.Action], entry.Name)
+	}
+}
+
+func validateEnvName(name string) error {
+	if !envVarName.MatchString(name) {
+		return fmt.Errorf("invalid environment variable name %q (must start with a letter, contain only letters, digits and underscores, and be at most 40 characters)", name)
+	}
+	return nil
+}
+
+// parseDotenv parses NAME=value lines. Blank lines, # comments and an
+// optional "export " prefix are accepted. Double-quoted values support \n,
+// \t, \" and \\ escapes; single-quoted values are taken literally.
+func parseDotenv(data []byte) (map[string]string, error) {
+	vars := make(map[string]string)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		idx := strings.Index(line, "=")
+		if idx <= 0 {
+			return nil, fmt.Errorf("line %d: expected NAME=value", lineNo)
+		}
+		name := strings.TrimSpace(line[:idx])
+		value, err := parseDotenvValue(strings.TrimSpace(line[idx+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		vars[name] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return vars, nil
+}
+
+func parseDotenvValue(raw string) (string, error) {
+	if raw == "" {
+		return "", nil
+	}
+
+	switch raw[0] {
+	case '\'':
+		end := strings.Index(raw[1:], "'")
+		if end < 0 {
+			return "", fmt.Errorf("unterminated single-quoted value")
+		}
+		return raw[1 : end+1], nil
+	case '"':
+		var b strings.Builder
+		for i := 1; i < len(raw); i++ {
+			c := raw[i]
+			if c == '"' {
+				return b.String(), nil
+			}
+			if c == '\\' && i+1 < len(raw) {
+				i++
+				switch raw[i] {
+				case 'n':
+					b.WriteByte('\n')
+				case 't':
+					b.WriteByte('\t')
+				default:
+					b.WriteByte(raw[i])
+				}
+				continue
+			}
+			b.WriteByte(c)
+		}
+		return "", fmt.Errorf("unterminated double-quoted value")
+	}
+
+	// Unquoted values end at an inline " #" comment
+	if idx := strings.Index(raw, " #"); idx >= 0 {
+		raw = raw[:idx]
+	}
+	return strings.TrimSpace(raw), nil
+}
+
+// listEnvVars returns the deployment's environment variables
+func (c *adminClient) listEnvVars() (map[string]string, error) {
+	var resp struct {
+		EnvironmentVariables map[string]string `json:"environmentVariables"`
+	}
+	if err := c.do("GET", "/api/v1/list_environment_variables", nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to list environment variables: %w", err)
+	}
+	if resp.EnvironmentVariables == nil {
+		resp.EnvironmentVariables = map[string]string{}
+	}
+	return resp.EnvironmentVariables, nil
+}
+
+// updateEnvVars applies the changes in a single request
+func (c *adminClient) updateEnvVars(changes []envChange) error {
+	body := struct {
+		Changes []envChange `json:"changes"`
+	}{changes}
+	if err := c.do("POST", "/api/v1/update_environment_variables", body, nil); err != nil {
+		return fmt.Errorf("failed to update environment variables: %w", err)
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseDotenv(t *testing.T) {
+	data := []byte(`# comment
+PLAIN=value
+export EXPORTED=yes
+SPACED = padded value  # trailing comment
+DOUBLE="line1\nline2 \"quoted\""
+SINGLE='no \n escapes # here'
+EMPTY=
+URL=https://example.com/#anchor
+`)
+
+	vars, err := parseDotenv(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"PLAIN":    "value",
+		"EXPORTED": "yes",
+		"SPACED":   "padded value",
+		"DOUBLE":   "line1\nline2 \"quoted\"",
+		"SINGLE":   `no \n escapes # here`,
+		"EMPTY":    "",
+		"URL":      "https://example.com/#anchor",
+	}
+	if !reflect.DeepEqual(vars, want) {
+		t.Errorf("got %#v\nwant %#v", vars, want)
+	}
+}
+
+func TestParseDotenv_Errors(t *testing.T) {
+	for _, data := range []string{"NOEQUALS", "=value", `A="unterminated`, "A='unterminated"} {
+		if _, err := parseDotenv([]byte(data)); err == nil {
+			t.Errorf("expected error for %q", data)
+		}
+	}
+}
+
+func TestDiffEnv(t *testing.T) {
+	current := map[string]string{"KEEP": "1", "CHANGE": "old", "DROP": "x"}
+	desired := map[string]string{"KEEP": "1", "CHANGE": "new", "ADD": "y"}
+
+	changes, diff := diffEnv(current, desired)
+
+	wantDiff := []EnvDiffEntry{
+		{Name: "ADD", Action: envActionAdd},
+		{Name: "CHANGE", Action: envActionChange},
+		{Name: "DROP", Action: envActionRemove},
+	}
+	if !reflect.DeepEqual(diff, wantDiff) {
+		t.Errorf("diff = %+v", diff)
+	}
+	if len(changes) != 3 || changes[2].Name != "DROP" || changes[2].Value != nil {
+		t.Errorf("changes = %+v", changes)
+	}
+}
+
+func TestEnvImport(t *testing.T) {
+	stub, server := newStubBackend(t, "test|key")
+	useStubBackend(t, server, "test|key")
+	stub.env["EXISTING"] = "1"
+	stub.env["STALE"] = "old"
+
+	file := filepath.Join(t.TempDir(), ".env")
+	os.WriteFile(file, []byte("EXISTING=1\nNEW=hello\n"), 0644)
+
+	flagQuiet = true
+	defer func() { flagQuiet = false; envImportPrune = false; envImportDryRun = false }()
+
+	envImportDryRun = true
+	if err := runEnvImport(envImportCmd, []string{file}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := stub.env["NEW"]; ok {
+		t.Fatal("dry run applied changes")
+	}
+
+	envImportDryRun = false
+	envImportPrune = true
+	if err := runEnvImport(envImportCmd, []string{file}); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"EXISTING": "1", "NEW": "hello"}
+	if !reflect.DeepEqual(stub.env, want) {
+		t.Errorf("env = %v, want %v", stub.env, want)
+	}
+
+	if err := runEnvUnset(envUnsetCmd, []string{"MISSING"}); err == nil {
+		t.Error("expected error unsetting a missing variable")
+	}
+}
@@ -0,0 +1,337 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// exporterUnitPath is the systemd unit written by 'exporter install'
+const exporterUnitPath = "/etc/systemd/system/convex-backend-exporter.service"
+
+// exporterSnapshot is the data behind one metrics scrape
+type exporterSnapshot struct {
+	Status  StatusOutput
+	Backups []BackupInfo
+}
+
+var (
+	exporterListen string
+	exporterMaxAge time.Duration
+)
+
+var exporterCmd = &cobra.Command{
+	Use:   "exporter",
+	Short: "Serve Prometheus metrics for the installation",
+	Long: `Serve Prometheus metrics on --listen (default 127.0.0.1:9810) at /metrics: backend
+health and health check latency, service state and restarts, process memory
+and CPU, database and storage size, disk space, backup inventory, installed
+version and the outcome of the last upgrade.
+
+The metrics are gathered from the same sources as 'status' and 'list-backups'.
+Results are reused for --cache so frequent scrapes do not re-walk the data
+directory. Use 'exporter install' to run the exporter as its own systemd unit.
+
+The metrics include versions and paths of the installation. Use e.g.
+--listen :9810 only if Prometheus scrapes from another host, and restrict the
+port with a firewall.`,
+	Args: cobra.NoArgs,
+	RunE: runExporter,
+}
+
+var exporterInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install and start the exporter as a systemd unit",
+	Long: `Write /etc/systemd/system/convex-backend-exporter.service running this binary's
+exporter with the given --listen address, then enable and start it.
+
+The unit runs as an unprivileged dynamic user with a read-only view of the
+system and no access to the credential files.`,
+	Args: cobra.NoArgs,
+	RunE: runExporterInstall,
+}
+
+var exporterUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Stop and remove the exporter systemd unit",
+	Args:  cobra.NoArgs,
+	RunE:  runExporterUninstall,
+}
+
+func init() {
+	rootCmd.AddCommand(exporterCmd)
+	exporterCmd.AddCommand(exporterInstallCmd)
+	exporterCmd.AddCommand(exporterUninstallCmd)
+	exporterCmd.PersistentFlags().StringVar(&exporterListen, "listen", "127.0.0.1:9810", "Address to serve metrics on")
+	exporterCmd.PersistentFlags().DurationVar(&exporterMaxAge, "cache", 10*time.Second, "Reuse collected metrics for this long")
+}
+
+func runExporter(cmd *cobra.Command, args []string) error {
+	handler := &metricsHandler{collect: collectExporterSnapshot, maxAge: exporterMaxAge}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", handler)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintln(w, `<html><body><h1>Convex backend exporter</h1><a href="/metrics">Metrics</a></body></html>`)
+	})
+
+	server := &http.Server{Addr: exporterListen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	printInfo("Serving metrics on %s/metrics", exporterListen)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("exporter failed: %w", err)
+	}
+	return nil
+}
+
+func runExporterInstall(cmd *cobra.Command, args []string) error {
+	if err := checkRoot(); err != nil {
+		return err
+	}
+	if err := checkSystemd(); err != nil {
+		return err
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate this binary: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	if strings.HasPrefix(exe, os.TempDir()) {
+		printInfo("Warning: %s is in a temporary directory, the exporter unit will break if it is removed", exe)
+	}
+
+	if err := os.WriteFile(exporterUnitPath, []byte(renderExporterUnit(exe, exporterListen)), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", exporterUnitPath, err)
+	}
+	if err := daemonReload(); err != nil {
+		return fmt.Errorf("failed to reload systemd: %w", err)
+	}
+	if err := exporterService.enable(true); err != nil {
+		return fmt.Errorf("failed to start exporter: %w", err)
+	}
+
+	printSuccess("Exporter installed, serving metrics on %s/metrics", exporterListen)
+	return nil
+}
+
+func runExporterUninstall(cmd *cobra.Command, args []string) error {
+	if err := checkRoot(); err != nil {
+		return err
+	}
+
+	exporterService.disable(true)
+	if err := os.Remove(exporterUnitPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove %s: %w", exporterUnitPath, err)
+	}
+	daemonReload()
+
+	printSuccess("Exporter uninstalled")
+	return nil
+}
+
+// renderExporterUnit returns the systemd unit that runs the exporter
+func renderExporterUnit(exe, listen string) string {
+	return fmt.Sprintf(`[Unit]
+Description=Convex Backend Prometheus Exporter
+After=network.target convex-backend.service
+
+[Service]
+Type=simple
+ExecStart=%s exporter --listen %s
+Restart=always
+RestartSec=5
+
+# The exporter only reads the installation and asks systemd for unit state
+DynamicUser=yes
+NoNewPrivileges=yes
+ProtectSystem=strict
+ProtectHome=read-only
+PrivateTmp=yes
+PrivateDevices=yes
+ProtectKernelTunables=yes
+ProtectKernelModules=yes
+ProtectControlGroups=yes
+RestrictAddressFamilies=AF_UNIX AF_INET AF_INET6
+ReadOnlyPaths=/var/lib/convex /etc/convex
+InaccessiblePaths=-/etc/convex/admin.key -/etc/convex/instance.secret
+
+[Install]
+WantedBy=multi-user.target
+`, exe, listen)
+}
+
+// metricsHandler serves the metrics, collecting at most once per maxAge
+type metricsHandler struct {
+	collect func() exporterSnapshot
+	maxAge  time.Duration
+
+	mu          sync.Mutex
+	collectedAt time.Time
+	body        []byte
+}
+
+func (h *metricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	if h.body == nil || time.Since(h.collectedAt) >= h.maxAge {
+		var buf bytes.Buffer
+		writeMetrics(&buf, h.collect(), time.Now())
+		h.body = buf.Bytes()
+		h.collectedAt = time.Now()
+	}
+	body := h.body
+	h.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write(body)
+}
+
+// collectExporterSnapshot gathers the data for one scrape
+func collectExporterSnapshot() exporterSnapshot {
+	snap := exporterSnapshot{Status: collectStatus()}
+	snap.Backups, _ = listBackups("/var/lib/convex/backups")
+	return snap
+}
+
+// metricsWriter writes the Prometheus text exposition format, emitting the
+// HELP and TYPE lines once per metric family
+type metricsWriter struct {
+	w    io.Writer
+	seen map[string]bool
+}
+
+func (m *metricsWriter) metric(kind, name, help string, value float64, labels ...string) {
+	if !m.seen[name] {
+		m.seen[name] = true
+		fmt.Fprintf(m.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+
+	var pairs []string
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, labels[i], labelEscaper.Replace(labels[i+1])))
+	}
+	if len(pairs) > 0 {
+		fmt.Fprintf(m.w, "%s{%s} %g\n", name, strings.Join(pairs, ","), value)
+	} else {
+		fmt.Fprintf(m.w, "%s %g\n", name, value)
+	}
+}
+
+// labelEscaper escapes label values as the exposition format requires
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func (m *metricsWriter) gauge(name, help string, value float64, labels ...string) {
+	m.metric("gauge", name, help, value, labels...)
+}
+
+func (m *metricsWriter) counter(name, help string, value float64, labels ...string) {
+	m.metric("counter", name, help, value, labels...)
+}
+
+func boolMetric(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// writeMetrics renders a snapshot as Prometheus metrics
+func writeMetrics(w io.Writer, snap exporterSnapshot, now time.Time) {
+	m := &metricsWriter{w: w, seen: make(map[string]bool)}
+	s := snap.Status
+
+	m.gauge("convex_backend_installed", "Whether the Convex backend is installed.", boolMetric(s.Installed))
+	if s.Manifest != nil {
+		m.gauge("convex_backend_info", "Installed bundle and backend versions.", 1,
+			"name", s.Manifest.Name, "version", s.Manifest.Version, "backend_version", s.BackendVersion, "ops_version", Version)
+	}
+
+	m.gauge("convex_backend_up", "Whether all health checks pass.", boolMetric(s.Health == "healthy"))
+	for _, hc := range s.HealthChecks {
+		m.gauge("convex_backend_health_check_up", "Whether a health check passes.", boolMetric(hc.Passed), "check", hc.Name)
+	}
+	for _, hc := range s.HealthChecks {
+		m.gauge("convex_backend_health_check_latency_seconds", "Latency of a health check.", float64(hc.LatencyMs)/1000, "check", hc.Name)
+	}
+
+	m.gauge("convex_backend_service_active", "Whether the systemd service is active.", boolMetric(s.ServiceStatus == "active"))
+	m.gauge("convex_backend_service_enabled", "Whether the systemd service is enabled.", boolMetric(s.ServiceEnabled))
+
+	if p := s.Process; p != nil {
+		m.gauge("convex_backend_service_restarts", "Restarts by systemd since the service was last started.", float64(p.Restarts))
+		m.gauge("convex_backend_process_resident_memory_bytes", "Resident set size of the backend process.", float64(p.RSSBytes))
+		m.gauge("convex_backend_service_memory_bytes", "Memory used by the service's cgroup.", float64(p.MemoryBytes))
+		m.counter("convex_backend_process_cpu_seconds_total", "CPU time used by the service.", float64(p.CPUSeconds))
+		if started, err := time.Parse(time.RFC3339, p.StartedAt); err == nil {
+			m.gauge("convex_backend_process_start_time_seconds", "Unix time the service was started.", float64(started.Unix()))
+		}
+	}
+
+	if st := s.Storage; st != nil {
+		m.gauge("convex_backend_database_size_bytes", "Size of the database including its write-ahead log.", float64(st.DatabaseBytes))
+		m.gauge("convex_backend_storage_objects", "Number of file storage objects.", float64(st.StorageObjects))
+		m.gauge("convex_backend_storage_size_bytes", "Size of file storage.", float64(st.StorageBytes))
+		m.gauge("convex_backend_disk_free_bytes", "Free bytes on the data volume.", float64(st.DiskFreeBytes))
+		m.gauge("convex_backend_disk_size_bytes", "Size of the data volume.", float64(st.DiskTotalBytes))
+		m.gauge("convex_backend_disk_free_inodes", "Free inodes on the data volume.", float64(st.DiskFreeInodes))
+	}
+
+	var backupBytes int64
+	for _, b := range snap.Backups {
+		backupBytes += b.Size
+	}
+	m.gauge("convex_backend_backups", "Number of backups.", float64(len(snap.Backups)))
+	m.gauge("convex_backend_backups_size_bytes", "Total size of all backups.", float64(backupBytes))
+	if len(snap.Backups) > 0 {
+		if created, err := time.Parse(time.RFC3339, snap.Backups[0].Created); err == nil {
+			m.gauge("convex_backend_last_backup_timestamp_seconds", "Unix time of the newest backup.", float64(created.Unix()))
+			m.gauge("convex_backend_last_backup_age_seconds", "Age of the newest backup.", now.Sub(created).Seconds())
+		}
+	}
+
+	if upgrade := lastUpgrade(snap.Backups); upgrade != nil {
+		m.gauge("convex_backend_last_upgrade_info", "Versions and outcome of the last upgrade.", 1,
+			"from_version", upgrade.Version, "to_version", upgrade.ToVersion, "outcome", upgrade.Outcome)
+		m.gauge("convex_backend_last_upgrade_success", "Whether the last upgrade succeeded.", boolMetric(upgrade.Outcome == hookOutcomeSuccess))
+		if created, err := time.Parse(time.RFC3339, upgrade.Created); err == nil {
+			m.gauge("convex_backend_last_upgrade_timestamp_seconds", "Unix time of the last upgrade.", float64(created.Unix()))
+		}
+	}
+}
+
+// lastUpgrade returns the backup made by the most recent upgrade, given
+// backups sorted newest first
+func lastUpgrade(backups []BackupInfo) *BackupInfo {
+	for i := range backups {
+		if backups[i].Reason == "upgrade" {
+			return &backups[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testExporterSnapshot() exporterSnapshot {
+	return exporterSnapshot{
+		Status: StatusOutput{
+			Installed:      true,
+			Manifest:       &Manifest{Name: `My "Backend"`, Version: "1.5.0"},
+			BackendVersion: "0.1.0",
+			ServiceStatus:  "active",
+			ServiceEnabled: true,
+			Health:         "healthy",
+			HealthChecks:   []HealthCheckResult{{Name: "version", Passed: true, LatencyMs: 250}},
+			Process:        &ServiceProcess{PID: 4242, Restarts: 2, RSSBytes: 1000, MemoryBytes: 2000, CPUSeconds: 90, StartedAt: "2025-01-15T10:00:00Z"},
+			Storage:        &StorageStatus{DatabaseBytes: 4096, StorageObjects: 3, StorageBytes: 300, DiskFreeBytes: 5000, DiskTotalBytes: 10000},
+		},
+		Backups: []BackupInfo{
+			{Version: "1.5.0", Created: "2025-01-15T12:00:00Z", Size: 100, Reason: "manual"},
+			{Version: "1.4.0", Created: "2025-01-14T00:00:00Z", Size: 200, Reason: "upgrade", ToVersion: "1.5.0", Outcome: "success"},
+			{Version: "1.3.0", Created: "2025-01-10T00:00:00Z", Size: 300, Reason: "upgrade", ToVersion: "1.4.0", Outcome: "rolled-back"},
+		},
+	}
+}
+
+func TestWriteMetrics(t *testing.T) {
+	var buf bytes.Buffer
+	writeMetrics(&buf, testExporterSnapshot(), time.Date(2025, 1, 15, 13, 0, 0, 0, time.UTC))
+	out := buf.String()
+
+	for _, want := range []string{
+		"# TYPE convex_backend_up gauge\nconvex_backend_up 1\n",
+		`convex_backend_info{name="My \"Backend\"",version="1.5.0",backend_version="0.1.0",ops_version="dev"} 1`,
+		`convex_backend_health_check_latency_seconds{check="version"} 0.25`,
+		"convex_backend_service_restarts 2\n",
+		"convex_backend_process_resident_memory_bytes 1000\n",
+		"# TYPE convex_backend_process_cpu_seconds_total counter\nconvex_backend_process_cpu_seconds_total 90\n",
+		"convex_backend_process_start_time_seconds 1.7369352e+09\n",
+		"convex_backend_database_size_bytes 4096\n",
+		"convex_backend_backups 3\n",
+		"convex_backend_backups_size_bytes 600\n",
+		"convex_backend_last_backup_age_seconds 3600\n",
+		`convex_backend_last_upgrade_info{from_version="1.4.0",to_version="1.5.0",outcome="success"} 1`,
+		"convex_backend_last_upgrade_success 1\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics are missing %q:\n%s", want, out)
+		}
+	}
+
+	if strings.Count(out, "# HELP convex_backend_health_check_up ") != 1 {
+		t.Error("expected a single HELP line per metric family")
+	}
+}
+
+func TestWriteMetrics_NotInstalled(t *testing.T) {
+	var buf bytes.Buffer
+	writeMetrics(&buf, exporterSnapshot{Status: StatusOutput{ServiceStatus: "inactive", Health: "unhealthy"}}, time.Now())
+	out := buf.String()
+
+	if !strings.Contains(out, "convex_backend_installed 0\n") || !strings.Contains(out, "convex_backend_up 0\n") {
+		t.Errorf("unexpected metrics:\n%s", out)
+	}
+	if strings.Contains(out, "convex_backend_last_upgrade") {
+		t.Error("expected no upgrade metrics without backups")
+	}
+}
+
+func TestMetricsHandler_Caches(t *testing.T) {
+	collections := 0
+	handler := &metricsHandler{
+		collect: func() exporterSnapshot {
+			collections++
+			return testExporterSnapshot()
+		},
+		maxAge: time.Minute,
+	}
+
+	for i := 0; i < 3; i++ {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+		if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+			t.Errorf("unexpected content type %q", rec.Header().Get("Content-Type"))
+		}
+		if !strings.Contains(rec.Body.String(), "convex_backend_up 1") {
+			t.Error("expected metrics in the response")
+		}
+	}
+	if collections != 1 {
+		t.Errorf("expected 1 collection within the cache period, got %d", collections)
+	}
+}
+
+func TestRenderExporterUnit(t *testing.T) {
+	unit := renderExporterUnit("/usr/local/bin/convex-backend-ops", "127.0.0.1:9810")
+	if !strings.Contains(unit, "ExecStart=/usr/local/bin/convex-backend-ops exporter --listen 127.0.0.1:9810\n") {
+		t.Errorf("unexpected unit:\n%s", unit)
+	}
+	for _, want := range []string{"DynamicUser=yes\n", "ProtectSystem=strict\n", "ReadOnlyPaths=/var/lib/convex /etc/convex\n", "InaccessiblePaths=-/etc/convex/admin.key"} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("expected %q in the unit:\n%s", want, unit)
+		}
+	}
+}
+
+func TestParseVmRSS(t *testing.T) {
+	status := "Name:\tconvex-backend\nVmPeak:\t  900000 kB\nVmRSS:\t  524288 kB\nThreads:\t12\n"
+	if got := parseVmRSS(status); got != 512<<20 {
+		t.Errorf("parseVmRSS = %d, want %d", got, 512<<20)
+	}
+}
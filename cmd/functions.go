@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// functionsPackage is a pre-built Convex functions package as produced by
+// convex-bundler:
+//
+//	functions/
+//	  config.json        optional {"authInfo": [...], "udfServerVersion": "..."}
+//	  modules/*.js       bundled function modules, including schema.js
+//	  modules/*.js.map   optional source maps
+type functionsPackage struct {
+	Config  functionsConfig
+	Modules []functionsModule
+}
+
+// functionsConfig is the optional config.json of a functions package
+type functionsConfig struct {
+	AuthInfo         []json.RawMessage `json:"authInfo,omitempty"`
+	UdfServerVersion string            `json:"udfServerVersion,omitempty"`
+}
+
+// functionsModule is a single bundled module in the push_config format
+type functionsModule struct {
+	Path        string  `json:"path"`
+	Source      string  `json:"source"`
+	SourceMap   *string `json:"sourceMap,omitempty"`
+	Environment string  `json:"environment"`
+}
+
+// pushConfigRequest is the body of POST /api/push_config
+type pushConfigRequest struct {
+	AdminKey         string            `json:"adminKey"`
+	Config           pushConfigConfig  `json:"config"`
+	Modules          []functionsModule `json:"modules"`
+	NodeDependencies []interface{}     `json:"nodeDependencies"`
+	UdfServerVersion string            `json:"udfServerVersion,omitempty"`
+}
+
+type pushConfigConfig struct {
+	Functions string            `json:"functions"`
+	AuthInfo  []json.RawMessage `json:"authInfo"`
+}
+
+// configHashesResponse is the body returned by POST /api/get_config_hashes
+type configHashesResponse struct {
+	ModuleHashes     []moduleHash `json:"moduleHashes"`
+	UdfServerVersion string       `json:"udfServerVersion,omitempty"`
+}
+
+// moduleHash is the SHA-256 of a deployed module's source
+type moduleHash struct {
+	Path string `json:"path"`
+	Hash string `json:"hash"`
+}
+
+// ModuleDiff lists how a functions package differs from the deployed modules
+type ModuleDiff struct {
+	Added     []string `json:"added"`
+	Modified  []string `json:"modified"`
+	Removed   []string `json:"removed"`
+	Unchanged int      `json:"unchanged"`
+}
+
+// loadFunctionsPackage reads a functions package directory
+func loadFunctionsPackage(dir string) (*functionsPackage, error) {
+	pkg := &functionsPackage{}
+
+	if data, err := os.ReadFile(filepath.Join(dir, "config.json")); err == nil {
+		if err := json.Unmarshal(data, &pkg.Config); err != nil {
+			return nil, fmt.Errorf("invalid functions config.json: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read functions config.json: %w", err)
+	}
+
+	modulesDir := filepath.Join(dir, "modules")
+	if info, err := os.Stat(modulesDir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("functions package has no modules directory: %s", modulesDir)
+	}
+
+	err := filepath.Walk(modulesDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(path, ".js") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(modulesDir, path)
+		if err != nil {
+			return err
+		}
+
+		source, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read module %s: %w", rel, err)
+		}
+
+		module := functionsModule{
+			Path:        filepath.ToSlash(rel),
+			Source:      string(source),
+			Environment: "isolate",
+		}
+		if sourceMap, err := os.ReadFile(path + ".map"); err == nil {
+			s := string(sourceMap)
+			module.SourceMap = &s
+		}
+
+		pkg.Modules = append(pkg.Modules, module)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pkg.Modules) == 0 {
+		return nil, fmt.Errorf("functions package has no modules: %s", modulesDir)
+	}
+
+	sort.Slice(pkg.Modules, func(i, j int) bool {
+		return pkg.Modules[i].Path < pkg.Modules[j].Path
+	})
+
+	return pkg, nil
+}
+
+// size returns the total size of the module sources in bytes
+func (p *functionsPackage) size() int64 {
+	var total int64
+	for _, m := range p.Modules {
+		total += int64(len(m.Source))
+	}
+	return total
+}
+
+// pushFunctions replaces the functions deployed in the backend with the
+// package. The backend applies the push atomically, so on error the
+// previously deployed functions keep running.
+func (c *adminClient) pushFunctions(pkg *functionsPackage) error {
+	authInfo := pkg.Config.AuthInfo
+	if authInfo == nil {
+		authInfo = []json.RawMessage{}
+	}
+
+	req := pushConfigRequest{
+		AdminKey: c.adminKey,
+		Config: pushConfigConfig{
+			Functions: "convex/",
+			AuthInfo:  authInfo,
+		},
+		Modules:          pkg.Modules,
+		NodeDependencies: []interface{}{},
+		UdfServerVersion: pkg.Config.UdfServerVersion,
+	}
+
+	if err := c.do("POST", "/api/push_config", req, nil); err != nil {
+		return fmt.Errorf("failed to push functions: %w", err)
+	}
+	return nil
+}
+
+// getModuleHashes returns the hashes of the modules currently deployed
+func (c *adminClient) getModuleHashes() ([]moduleHash, error) {
+	var resp configHashesResponse
+	if err := c.do("POST", "/api/get_config_hashes", map[string]string{"adminKey": c.adminKey}, &resp); err != nil {
+		return nil, fmt.Errorf("failed to read deployed functions: %w", err)
+	}
+	return resp.ModuleHashes, nil
+}
+
+// hashModuleSource returns the hex SHA-256 used to compare module sources
+func hashModuleSource(source string) string {
+	sum := sha256.Sum256([]byte(source))
+	return hex.EncodeToString(sum[:])
+}
+
+// diffModules compares a package against the deployed module hashes
+func diffModules(pkg *functionsPackage, deployed []moduleHash) ModuleDiff {
+	diff := ModuleDiff{Added: []string{}, Modified: []string{}, Removed: []string{}}
+
+	remote := make(map[string]string, len(deployed))
+	for _, m := range deployed {
+		remote[m.Path] = m.Hash
+	}
+
+	local := make(map[string]bool, len(pkg.Modules))
+	for _, m := range pkg.Modules {
+		local[m.Path] = true
+		hash, ok := remote[m.Path]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, m.Path)
+		case hash != hashModuleSource(m.Source):
+			diff.Modified = append(diff.Modified, m.Path)
+		default:
+			diff.Unchanged++
+		}
+	}
+
+	for _, m := range deployed {
+		if !local[m.Path] {
+			diff.Removed = append(diff.Removed, m.Path)
+		}
+	}
+	sort.Strings(diff.Removed)
+
+	return diff
+}
+
+// hasChanges reports whether deploying would change any module
+func (d ModuleDiff) hasChanges() bool {
+	return len(d.Added)+len(d.Modified)+len(d.Removed) > 0
+}
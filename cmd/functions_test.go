@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestFunctions creates a functions package directory
+func writeTestFunctions(t *testing.T, dir string, modules map[string]string) {
+	t.Helper()
+
+	files := map[string]string{
+		"config.json": `{"authInfo":[],"udfServerVersion":"1.16.0"}`,
+	}
+	for name, source := range modules {
+		files[filepath.Join("modules", name)] = source
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadFunctionsPackage(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFunctions(t, dir, map[string]string{
+		"messages.js":     "export const list = 1;",
+		"messages.js.map": "{}",
+		"schema.js":       "export default {};",
+		"_deps/chunk.js":  "export const x = 2;",
+	})
+
+	pkg, err := loadFunctionsPackage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var paths []string
+	for _, m := range pkg.Modules {
+		paths = append(paths, m.Path)
+	}
+	if got := strings.Join(paths, ","); got != "_deps/chunk.js,messages.js,schema.js" {
+		t.Errorf("modules = %s", got)
+	}
+	if pkg.Modules[1].SourceMap == nil || *pkg.Modules[1].SourceMap != "{}" {
+		t.Errorf("messages.js source map not loaded")
+	}
+	if pkg.Config.UdfServerVersion != "1.16.0" {
+		t.Errorf("udfServerVersion = %q", pkg.Config.UdfServerVersion)
+	}
+}
+
+func TestLoadFunctionsPackage_NoModules(t *testing.T) {
+	if _, err := loadFunctionsPackage(t.TempDir()); err == nil {
+		t.Fatal("expected error for package without modules")
+	}
+}
+
+func TestPushFunctions(t *testing.T) {
+	var got pushConfigRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/push_config" {
+			http.NotFound(w, r)
+			return
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Convex test|key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	writeTestFunctions(t, dir, map[string]string{"messages.js": "export const list = 1;"})
+	pkg, err := loadFunctionsPackage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &adminClient{baseURL: server.URL, adminKey: "test|key", client: server.Client()}
+	if err := client.pushFunctions(pkg); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got.Modules) != 1 || got.Modules[0].Path != "messages.js" || got.Modules[0].Environment != "isolate" {
+		t.Errorf("pushed modules = %+v", got.Modules)
+	}
+	if got.AdminKey != "test|key" || got.Config.Functions != "convex/" {
+		t.Errorf("unexpected push request: %+v", got)
+	}
+}
+
+func TestPushFunctions_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":"InvalidModules","message":"schema.js failed to evaluate"}`))
+	}))
+	defer server.Close()
+
+	client := &adminClient{baseURL: server.URL, adminKey: "k", client: server.Client()}
+	err := client.pushFunctions(&functionsPackage{Modules: []functionsModule{{Path: "schema.js"}}})
+	if err == nil || !strings.Contains(err.Error(), "schema.js failed to evaluate") {
+		t.Fatalf("expected backend error message, got %v", err)
+	}
+}
+
+func TestDiffModules(t *testing.T) {
+	pkg := &functionsPackage{Modules: []functionsModule{
+		{Path: "a.js", Source: "same"},
+		{Path: "b.js", Source: "new source"},
+		{Path: "c.js", Source: "added"},
+	}}
+	deployed := []moduleHash{
+		{Path: "a.js", Hash: hashModuleSource("same")},
+		{Path: "b.js", Hash: hashModuleSource("old source")},
+		{Path: "old.js", Hash: "deadbeef"},
+	}
+
+	diff := diffModules(pkg, deployed)
+	if strings.Join(diff.Added, ",") != "c.js" || strings.Join(diff.Modified, ",") != "b.js" ||
+		strings.Join(diff.Removed, ",") != "old.js" || diff.Unchanged != 1 {
+		t.Errorf("unexpected diff: %+v", diff)
+	}
+	if !diff.hasChanges() {
+		t.Error("expected changes")
+	}
+
+	if diffModules(pkg, []moduleHash{
+		{Path: "a.js", Hash: hashModuleSource("same")},
+		{Path: "b.js", Hash: hashModuleSource("new source")},
+		{Path: "c.js", Hash: hashModuleSource("added")},
+	}).hasChanges() {
+		t.Error("expected no changes for identical modules")
+	}
+}
+
+func TestGetModuleHashes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/get_config_hashes" || r.Header.Get("Authorization") != "Convex k" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"config":{},"moduleHashes":[{"path":"messages.js","hash":"abc","environment":"isolate"}]}`))
+	}))
+	defer server.Close()
+
+	client := &adminClient{baseURL: server.URL, adminKey: "k", client: server.Client()}
+	hashes, err := client.getModuleHashes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hashes) != 1 || hashes[0].Path != "messages.js" || hashes[0].Hash != "abc" {
+		t.Errorf("unexpected hashes: %+v", hashes)
+	}
+}
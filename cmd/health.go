@@ -0,0 +1,263 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// HealthConfig configures the readiness checks run after install, upgrade and
+// rollback. It can be provided by the bundle manifest ("health") and
+// overridden on the host by /etc/convex/health.json.
+type HealthConfig struct {
+	Timeout   string            `json:"timeout,omitempty"`
+	Interval  string            `json:"interval,omitempty"`
+	Endpoints []string          `json:"endpoints,omitempty"`
+	SiteProxy *bool             `json:"siteProxy,omitempty"`
+	Checks    []HealthCheckSpec `json:"checks,omitempty"`
+}
+
+// HealthCheckSpec describes an HTTP action check against the site proxy
+type HealthCheckSpec struct {
+	Name         string `json:"name,omitempty"`
+	Method       string `json:"method,omitempty"`
+	Path         string `json:"path"`
+	ExpectStatus int    `json:"expectStatus,omitempty"`
+}
+
+// HealthCheckResult represents the result of a single health check
+type HealthCheckResult struct {
+	Name      string `json:"name"`
+	URL       string `json:"url"`
+	Passed    bool   `json:"passed"`
+	Status    int    `json:"status,omitempty"`
+	Error     string `json:"error,omitempty"`
+	LatencyMs int64  `json:"latencyMs"`
+}
+
+// healthSettings is the resolved health configuration
+type healthSettings struct {
+	backendURL string
+	siteURL    string
+	timeout    time.Duration
+	interval   time.Duration
+	endpoints  []string
+	siteProxy  bool
+	checks     []HealthCheckSpec
+}
+
+const healthConfigPath = "/etc/convex/health.json"
+
+// flagHealthTimeout overrides the configured health timeout when set
+var flagHealthTimeout time.Duration
+
+// loadHealthSettings resolves the health configuration from the defaults, the
+// installed manifest, /etc/convex/health.json and --health-timeout, in that order
+func loadHealthSettings() (*healthSettings, error) {
+	manifest, _ := readManifest("/var/lib/convex/manifest.json")
+	return loadHealthSettingsFor(manifest)
+}
+
+// loadHealthSettingsFor resolves the health configuration using the given
+// manifest instead of the installed one
+func loadHealthSettingsFor(manifest *Manifest) (*healthSettings, error) {
+	settings := &healthSettings{
+		backendURL: backendURL(),
+		siteURL:    "http://localhost:3211",
+		timeout:    30 * time.Second,
+		interval:   1 * time.Second,
+		endpoints:  []string{"/version"},
+	}
+
+	if manifest != nil && manifest.Health != nil {
+		if err := settings.apply(manifest.Health); err != nil {
+			return nil, fmt.Errorf("invalid health config in manifest: %w", err)
+		}
+	}
+
+	if data, err := os.ReadFile(healthConfigPath); err == nil {
+		var cfg HealthConfig
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", healthConfigPath, err)
+		}
+		if err := settings.apply(&cfg); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", healthConfigPath, err)
+		}
+	}
+
+	if flagHealthTimeout < 0 {
+		return nil, fmt.Errorf("--health-timeout must be positive, got %s", flagHealthTimeout)
+	}
+	if flagHealthTimeout > 0 {
+		settings.timeout = flagHealthTimeout
+	}
+
+	return settings, nil
+}
+
+func (s *healthSettings) apply(cfg *HealthConfig) error {
+	if cfg.Timeout != "" {
+		d, err := time.ParseDuration(cfg.Timeout)
+		if err != nil {
+			return fmt.Errorf("timeout: %w", err)
+		}
+		if d <= 0 {
+			return fmt.Errorf("timeout must be positive, got %s", cfg.Timeout)
+		}
+		s.timeout = d
+	}
+	if cfg.Interval != "" {
+		d, err := time.ParseDuration(cfg.Interval)
+		if err != nil {
+			return fmt.Errorf("interval: %w", err)
+		}
+		// A zero interval would poll the backend in a busy loop
+		if d <= 0 {
+			return fmt.Errorf("interval must be positive, got %s", cfg.Interval)
+		}
+		s.interval = d
+	}
+	if len(cfg.Endpoints) > 0 {
+		s.endpoints = cfg.Endpoints
+	}
+	if cfg.SiteProxy != nil {
+		s.siteProxy = *cfg.SiteProxy
+	}
+	if len(cfg.Checks) > 0 {
+		s.checks = cfg.Checks
+	}
+	return nil
+}
+
+// runHealthChecks runs every configured check once
+func runHealthChecks(s *healthSettings) []HealthCheckResult {
+	client := &http.Client{
+		Timeout: 5 * time.Second,
+	}
+
+	var results []HealthCheckResult
+	for _, endpoint := range s.endpoints {
+		results = append(results, probe(client, "backend:"+endpoint, http.MethodGet, joinURL(s.backendURL, endpoint), http.StatusOK))
+	}
+
+	if s.siteProxy {
+		// Any HTTP response means the site proxy is accepting requests
+		results = append(results, probe(client, "site-proxy", http.MethodGet, s.siteURL+"/", 0))
+	}
+
+	for _, check := range s.checks {
+		name := check.Name
+		if name == "" {
+			name = "app:" + check.Path
+		}
+		method := check.Method
+		if method == "" {
+			method = http.MethodGet
+		}
+		expect := check.ExpectStatus
+		if expect == 0 {
+			expect = http.StatusOK
+		}
+		results = append(results, probe(client, name, method, joinURL(s.siteURL, check.Path), expect))
+	}
+
+	return results
+}
+
+// probe issues a single request. expectStatus 0 accepts any status code.
+func probe(client *http.Client, name, method, url string, expectStatus int) HealthCheckResult {
+	result := HealthCheckResult{Name: name, URL: url}
+
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	result.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	resp.Body.Close()
+
+	result.Status = resp.StatusCode
+	if expectStatus != 0 && resp.StatusCode != expectStatus {
+		result.Error = fmt.Sprintf("expected status %d, got %d", expectStatus, resp.StatusCode)
+		return result
+	}
+
+	result.Passed = true
+	return result
+}
+
+// firstFailedCheck returns the first failing check, or nil if all passed
+func firstFailedCheck(results []HealthCheckResult) *HealthCheckResult {
+	for i := range results {
+		if !results[i].Passed {
+			return &results[i]
+		}
+	}
+	return nil
+}
+
+// waitForHealth polls the configured health checks until all of them pass or
+// the timeout expires. The error names the check that was still failing.
+func waitForHealth() error {
+	settings, err := loadHealthSettings()
+	if err != nil {
+		return err
+	}
+	return waitForHealthWith(context.Background(), settings)
+}
+
+// waitForHealthWith polls until the checks pass, the timeout expires or ctx
+// is cancelled
+func waitForHealthWith(ctx context.Context, settings *healthSettings) error {
+	var failed *HealthCheckResult
+
+	deadline := time.Now().Add(settings.timeout)
+	for time.Now().Before(deadline) {
+		failed = firstFailedCheck(runHealthChecks(settings))
+		if failed == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(settings.interval):
+		}
+	}
+
+	if failed != nil {
+		return fmt.Errorf("backend did not become healthy within %v: check %q failed: %s", settings.timeout, failed.Name, failed.Error)
+	}
+	return fmt.Errorf("backend did not become healthy within %v", settings.timeout)
+}
+
+// checkHealth runs the configured checks once and summarizes them
+func checkHealth() (string, []HealthCheckResult) {
+	settings, err := loadHealthSettings()
+	if err != nil {
+		return "unknown", []HealthCheckResult{{Name: "config", Error: err.Error()}}
+	}
+
+	results := runHealthChecks(settings)
+	if firstFailedCheck(results) != nil {
+		return "unhealthy", results
+	}
+	return "healthy", results
+}
+
+func joinURL(base, path string) string {
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return strings.TrimSuffix(base, "/") + path
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunHealthChecks(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/version" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer backend.Close()
+
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/relay/ping":
+			w.WriteHeader(http.StatusOK)
+		case "/created":
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer site.Close()
+
+	settings := &healthSettings{
+		backendURL: backend.URL,
+		siteURL:    site.URL,
+		endpoints:  []string{"/version"},
+		siteProxy:  true,
+		checks: []HealthCheckSpec{
+			{Name: "relay", Path: "/relay/ping"},
+			{Path: "created", Method: http.MethodPost, ExpectStatus: http.StatusCreated},
+		},
+	}
+
+	results := runHealthChecks(settings)
+	if len(results) != 4 {
+		t.Fatalf("expected 4 results, got %d", len(results))
+	}
+	if failed := firstFailedCheck(results); failed != nil {
+		t.Fatalf("unexpected failing check %s: %s", failed.Name, failed.Error)
+	}
+	if results[3].Name != "app:created" {
+		t.Errorf("unexpected default check name %q", results[3].Name)
+	}
+
+	settings.checks = append(settings.checks, HealthCheckSpec{Name: "missing", Path: "/missing"})
+	failed := firstFailedCheck(runHealthChecks(settings))
+	if failed == nil || failed.Name != "missing" || failed.Status != http.StatusNotFound {
+		t.Errorf("expected missing check to fail, got %+v", failed)
+	}
+}
+
+func TestWaitForHealth_NamesFailingCheck(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer backend.Close()
+
+	settings := &healthSettings{
+		backendURL: backend.URL,
+		timeout:    50 * time.Millisecond,
+		interval:   10 * time.Millisecond,
+		endpoints:  []string{"/version"},
+	}
+
+	err := waitForHealthWith(context.Background(), settings)
+	if err == nil || !strings.Contains(err.Error(), `check "backend:/version" failed`) {
+		t.Errorf("expected error naming failing check, got %v", err)
+	}
+}
+
+func TestWaitForHealth_StopsWhenCancelled(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer backend.Close()
+
+	settings := &healthSettings{
+		backendURL: backend.URL,
+		timeout:    time.Minute,
+		interval:   10 * time.Millisecond,
+		endpoints:  []string{"/version"},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	if err := waitForHealthWith(ctx, settings); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("polling continued for %v after cancel", elapsed)
+	}
+}
+
+func TestHealthSettingsApply(t *testing.T) {
+	enabled := true
+	settings := &healthSettings{timeout: 30 * time.Second, interval: time.Second, endpoints: []string{"/version"}}
+
+	err := settings.apply(&HealthConfig{Timeout: "2m", Endpoints: []string{"/version", "/instance_name"}, SiteProxy: &enabled})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.timeout != 2*time.Minute || settings.interval != time.Second || len(settings.endpoints) != 2 || !settings.siteProxy {
+		t.Errorf("unexpected settings %+v", settings)
+	}
+
+	if err := settings.apply(&HealthConfig{Interval: "soon"}); err == nil {
+		t.Error("expected error for invalid interval")
+	}
+	for _, cfg := range []HealthConfig{{Interval: "0s"}, {Interval: "-1s"}, {Timeout: "0s"}} {
+		if err := settings.apply(&cfg); err == nil || !strings.Contains(err.Error(), "must be positive") {
+			t.Errorf("expected %+v to be rejected, got %v", cfg, err)
+		}
+	}
+}
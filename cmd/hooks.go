@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Host hooks live in /etc/convex/hooks.d, bundle hooks in <bundle>/hooks.
+// A hook is either an executable named <phase>-<operation> (e.g. pre-upgrade)
+// or a directory <phase>-<operation>.d whose executables run in lexical order.
+const hostHooksDir = "/etc/convex/hooks.d"
+
+// Hook outcomes reported to post-operation hooks
+const (
+	hookOutcomeSuccess    = "success"
+	hookOutcomeFailure    = "failure"
+	hookOutcomeRolledBack = "rolled-back"
+)
+
+// hookContext describes an operation for the hooks that run around it
+type hookContext struct {
+	Operation   string
+	FromVersion string
+	ToVersion   string
+	BundlePath  string
+	BackupDir   string
+	RolledBack  bool
+}
+
+// runPre runs the pre-operation hooks. Any failing hook aborts the remaining
+// hooks and is returned so the caller can abort the operation.
+func (h *hookContext) runPre() error {
+	return runHooks("pre", h, nil)
+}
+
+// runPost runs the post-operation hooks with the outcome derived from opErr.
+// Failures are only reported since the operation has already completed.
+func (h *hookContext) runPost(opErr error) {
+	if err := runHooks("post", h, opErr); err != nil {
+		printError("post-%s hook failed: %v", h.Operation, err)
+	}
+}
+
+func runHooks(phase string, h *hookContext, opErr error) error {
+	name := phase + "-" + h.Operation
+
+	var dirs []string
+	if h.BundlePath != "" {
+		dirs = append(dirs, filepath.Join(h.BundlePath, "hooks"))
+	}
+	dirs = append(dirs, hostHooksDir)
+
+	var scripts []string
+	for _, dir := range dirs {
+		scripts = append(scripts, findHookScripts(dir, name)...)
+	}
+	if len(scripts) == 0 {
+		return nil
+	}
+
+	env := append(os.Environ(),
+		"CONVEX_OPS_HOOK="+name,
+		"CONVEX_OPS_OPERATION="+h.Operation,
+		"CONVEX_OPS_PHASE="+phase,
+		"CONVEX_OPS_FROM_VERSION="+h.FromVersion,
+		"CONVEX_OPS_TO_VERSION="+h.ToVersion,
+		"CONVEX_OPS_BUNDLE="+h.BundlePath,
+		"CONVEX_OPS_BACKUP_DIR="+h.BackupDir,
+		"CONVEX_OPS_BACKEND_URL=http://localhost:3210",
+	)
+	if phase == "post" {
+		outcome := hookOutcomeSuccess
+		if opErr != nil {
+			outcome = hookOutcomeFailure
+			if h.RolledBack {
+				outcome = hookOutcomeRolledBack
+			}
+			env = append(env, "CONVEX_OPS_ERROR="+opErr.Error())
+		}
+		env = append(env, "CONVEX_OPS_OUTCOME="+outcome)
+	}
+
+	timeout := getEnvDuration("CONVEX_HOOK_TIMEOUT", 5*time.Minute)
+	for _, script := range scripts {
+		printInfo("Running %s hook: %s", name, script)
+		if err := runHookScript(script, env, timeout); err != nil {
+			return fmt.Errorf("%s: %w", script, err)
+		}
+	}
+
+	return nil
+}
+
+// findHookScripts returns the executables for a hook name in dir
+func findHookScripts(dir, name string) []string {
+	path := filepath.Join(dir, name)
+	candidates := []string{path}
+
+	if entries, err := os.ReadDir(path + ".d"); err == nil {
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			candidates = append(candidates, filepath.Join(path+".d", n))
+		}
+	}
+
+	var scripts []string
+	for _, p := range candidates {
+		info, err := os.Stat(p)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if info.Mode()&0111 == 0 {
+			printInfo("Warning: skipping non-executable hook %s", p)
+			continue
+		}
+		scripts = append(scripts, p)
+	}
+	return scripts
+}
+
+func runHookScript(script string, env []string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, script)
+	cmd.Env = env
+	cmd.Dir = "/"
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("timed out after %v", timeout)
+		}
+		return err
+	}
+	return nil
+}
+
+// getEnvDuration reads a duration such as "90s" or "5m" from the environment
+func getEnvDuration(name string, defaultValue time.Duration) time.Duration {
+	if v := os.Getenv(name); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+	}
+	return defaultValue
+}
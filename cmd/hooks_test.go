@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeHook(t *testing.T, path, script string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFindHookScripts(t *testing.T) {
+	dir := t.TempDir()
+	writeHook(t, filepath.Join(dir, "pre-upgrade"), "true\n")
+	writeHook(t, filepath.Join(dir, "pre-upgrade.d", "20-notify"), "true\n")
+	writeHook(t, filepath.Join(dir, "pre-upgrade.d", "10-drain"), "true\n")
+	os.WriteFile(filepath.Join(dir, "pre-upgrade.d", "30-disabled"), []byte("true\n"), 0644)
+
+	flagQuiet = true
+	defer func() { flagQuiet = false }()
+
+	got := findHookScripts(dir, "pre-upgrade")
+	want := []string{
+		filepath.Join(dir, "pre-upgrade"),
+		filepath.Join(dir, "pre-upgrade.d", "10-drain"),
+		filepath.Join(dir, "pre-upgrade.d", "20-notify"),
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestRunHooks_Environment(t *testing.T) {
+	bundle := t.TempDir()
+	out := filepath.Join(t.TempDir(), "env")
+	writeHook(t, filepath.Join(bundle, "hooks", "post-upgrade"),
+		`echo "$CONVEX_OPS_OPERATION $CONVEX_OPS_FROM_VERSION $CONVEX_OPS_TO_VERSION $CONVEX_OPS_OUTCOME" > `+out+"\n")
+
+	flagQuiet = true
+	defer func() { flagQuiet = false }()
+
+	h := &hookContext{Operation: "upgrade", FromVersion: "1.0.0", ToVersion: "2.0.0", BundlePath: bundle, RolledBack: true}
+	if err := runHooks("post", h, errors.New("health check failed")); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(data)); got != "upgrade 1.0.0 2.0.0 rolled-back" {
+		t.Errorf("unexpected hook environment: %q", got)
+	}
+}
+
+func TestRunHooks_PreFailureAborts(t *testing.T) {
+	bundle := t.TempDir()
+	marker := filepath.Join(t.TempDir(), "ran")
+	writeHook(t, filepath.Join(bundle, "hooks", "pre-install.d", "10-fail"), "exit 3\n")
+	writeHook(t, filepath.Join(bundle, "hooks", "pre-install.d", "20-after"), "touch "+marker+"\n")
+
+	flagQuiet = true
+	defer func() { flagQuiet = false }()
+
+	h := &hookContext{Operation: "install", BundlePath: bundle}
+	if err := h.runPre(); err == nil {
+		t.Fatal("expected failing pre-install hook to return an error")
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("hooks after a failing hook should not run")
+	}
+}
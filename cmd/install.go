@@ -4,12 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
-	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -20,22 +18,50 @@ type Credentials struct {
 	InstanceSecret string `json:"instanceSecret"`
 }
 
-var installBundlePath string
+var (
+	installBundlePath          string
+	installGenerateCredentials bool
+	installInstanceName        string
+	installCredentialsOut      string
+	installSecretsFrom         string
+	installPassphraseFile      string
+)
 
 var installCmd = &cobra.Command{
 	Use:   "install",
 	Short: "Install Convex backend from a bundle",
-	Long:  `Install Convex backend from a bundle created by convex-bundler.`,
-	RunE:  runInstall,
+	Long: `Install Convex backend from a bundle created by convex-bundler.
+
+With --generate-credentials (or "generateCredentials": true in the bundle
+manifest) a unique instance secret and admin key are generated on this host
+instead of using the ones in the bundle's credentials.json, so every install
+has its own credentials. The bundle-provided secrets are never stored. Use
+--credentials-out to also write the generated credentials to a file.
+
+With --secrets-from (or "secretsFrom" in the bundle manifest) the credentials
+are read from an external source instead, so the bundle need not ship them:
+
+  file:<path>        credentials.json file
+  env[:<prefix>]     CONVEX_ADMIN_KEY and CONVEX_INSTANCE_SECRET
+  systemd[:<name>]   systemd credential (default name "convex")
+  keystore:<path>    encrypted keystore, unlocked with --passphrase-file
+  vault:<url>        Vault KV secret, read with VAULT_TOKEN`,
+	RunE: runInstall,
 }
 
 func init() {
 	rootCmd.AddCommand(installCmd)
 	installCmd.Flags().StringVarP(&installBundlePath, "bundle", "b", "", "Path to the bundle directory (uses embedded bundle if not specified)")
+	installCmd.Flags().DurationVar(&flagHealthTimeout, "health-timeout", 0, "How long to wait for health checks to pass (default from health config, 30s)")
+	installCmd.Flags().BoolVar(&installGenerateCredentials, "generate-credentials", false, "Generate a unique instance secret and admin key instead of using the bundle's")
+	installCmd.Flags().StringVar(&installInstanceName, "instance-name", "", "Instance name for generated credentials (default from the bundle's admin key, or \"convex\")")
+	installCmd.Flags().StringVar(&installCredentialsOut, "credentials-out", "", "Also write the installed credentials to this file (credentials.json format, mode 0600)")
+	installCmd.Flags().StringVar(&installSecretsFrom, "secrets-from", "", "Read the credentials from an external source instead of the bundle")
+	installCmd.Flags().StringVar(&installPassphraseFile, "passphrase-file", "", "File containing the passphrase for a keystore secret source")
 	// Note: --bundle is NOT marked as required because self-host executables have embedded bundles
 }
 
-func runInstall(cmd *cobra.Command, args []string) error {
+func runInstall(cmd *cobra.Command, args []string) (retErr error) {
 	// Pre-flight checks
 	if err := checkRoot(); err != nil {
 		return err
@@ -49,6 +75,10 @@ func runInstall(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if installGenerateCredentials && installSecretsFrom != "" {
+		return fmt.Errorf("--generate-credentials and --secrets-from cannot be combined")
+	}
+
 	// Determine bundle path - either from flag or from embedded bundle
 	bundlePath := installBundlePath
 	var cleanupFunc func()
@@ -77,9 +107,48 @@ func runInstall(cmd *cobra.Command, args []string) error {
 		defer cleanupFunc()
 	}
 
-	if err := validateBundle(bundlePath); err != nil {
+	bundleManifest, err := validateBundle(bundlePath, installCredentialsFromFlags())
+	if err != nil {
 		return fmt.Errorf("invalid bundle: %w", err)
 	}
+	if err := checkManifestCompatibility(bundleManifest); err != nil {
+		return err
+	}
+	if bundleManifest.isAppsOnly() {
+		return fmt.Errorf("bundle only carries app deployments. Install a full bundle first, then use 'upgrade' to deploy it")
+	}
+
+	// Resolve the credentials before touching the host, so a bad flag
+	// combination or an unreachable secret source leaves nothing behind
+	generated := installGenerateCredentials || (bundleManifest.GenerateCredentials && installSecretsFrom == "")
+	var creds *Credentials
+	if generated {
+		printInfo("Generating credentials...")
+		creds, err = generateHostCredentials(bundlePath, installInstanceName)
+		if err != nil {
+			return fmt.Errorf("failed to generate credentials: %w", err)
+		}
+	} else {
+		provider, err := installSecretProvider(bundlePath, bundleManifest)
+		if err != nil {
+			return err
+		}
+		printInfo("Reading credentials from %s...", provider.Name())
+		creds, err = provider.Load()
+		if err != nil {
+			return fmt.Errorf("failed to read credentials: %w", err)
+		}
+	}
+
+	hooks := &hookContext{
+		Operation:  "install",
+		ToVersion:  bundleManifest.Version,
+		BundlePath: bundlePath,
+	}
+	if err := hooks.runPre(); err != nil {
+		return fmt.Errorf("pre-install hook failed: %w", err)
+	}
+	defer func() { hooks.runPost(retErr) }()
 
 	printInfo("Installing Convex backend from bundle: %s", bundlePath)
 
@@ -95,13 +164,6 @@ func runInstall(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to copy bundle assets: %w", err)
 	}
 
-	// Extract credentials
-	printInfo("Extracting credentials...")
-	creds, err := extractCredentials(bundlePath)
-	if err != nil {
-		return fmt.Errorf("failed to extract credentials: %w", err)
-	}
-
 	if err := writeCredentials(creds); err != nil {
 		return fmt.Errorf("failed to write credentials: %w", err)
 	}
@@ -135,19 +197,40 @@ func runInstall(cmd *cobra.Command, args []string) error {
 
 	// Health check
 	printInfo("Waiting for backend to be ready...")
-	if err := waitForHealth("http://localhost:3210", 30*time.Second); err != nil {
+	if err := waitForHealth(); err != nil {
 		// Show logs on failure
 		showServiceLogs()
 		return fmt.Errorf("health check failed: %w", err)
 	}
 
+	if generated {
+		printInfo("Verifying generated admin key...")
+		if err := verifyAdminKey(); err != nil {
+			showServiceLogs()
+			// The bundle's shared secrets must never be installed in their place
+			if stopErr := backendService.stop(); stopErr != nil {
+				printError("Failed to stop service: %v", stopErr)
+			}
+			return fmt.Errorf("generated admin key was not accepted by the backend, service stopped: %w", err)
+		}
+	}
+
+	if installCredentialsOut != "" {
+		if err := writeCredentialsFile(installCredentialsOut, creds); err != nil {
+			return err
+		}
+	}
+	recordInstalledFilesOrWarn("install")
+
 	// Read manifest for output
 	manifest, _ := readManifest("/var/lib/convex/manifest.json")
 
 	printSuccess("Convex backend installed successfully!")
 	fmt.Println()
 	fmt.Println("Backend URL:  http://localhost:3210")
-	fmt.Printf("Admin Key:    %s\n", creds.AdminKey)
+	fmt.Printf("Admin Key:    %s\n", redactAdminKey(creds.AdminKey))
+	fmt.Println()
+	fmt.Println("Show the full admin key with: convex-backend-ops credentials show --reveal")
 	fmt.Println()
 
 	if manifest != nil && len(manifest.Apps) > 0 {
@@ -186,15 +269,30 @@ func checkNotInstalled() error {
 	return nil
 }
 
-func validateBundle(bundlePath string) error {
-	requiredFiles := []string{"backend", "convex.db", "manifest.json", "credentials.json"}
-	for _, f := range requiredFiles {
-		path := filepath.Join(bundlePath, f)
-		if _, err := os.Stat(path); os.IsNotExist(err) {
-			return fmt.Errorf("missing required file: %s", f)
-		}
+// installCredentialsFromFlags reports whether the install flags replace the
+// bundle's credentials, which then need not exist
+func installCredentialsFromFlags() bool {
+	return installGenerateCredentials || installSecretsFrom != ""
+}
+
+// validateBundle gates install and upgrade on the bundle's required files
+// and returns the bundle manifest. The rest of the report, including manifest
+// fields this build does not understand, is shown as warnings; 'bundle
+// validate' is the strict check. credentialsProvided drops the
+// credentials.json requirement when the operation does not take its
+// credentials from the bundle.
+func validateBundle(bundlePath string, credentialsProvided bool) (*Manifest, error) {
+	report := inspectBundleFor(bundlePath, credentialsProvided)
+	if len(report.missing) > 0 || report.Manifest == nil {
+		return nil, fmt.Errorf("%s", strings.Join(report.Errors, "; "))
 	}
-	return nil
+	for _, e := range report.Errors {
+		printInfo("Warning: %s", e)
+	}
+	for _, w := range report.Warnings {
+		printInfo("Warning: %s", w)
+	}
+	return report.Manifest, nil
 }
 
 func createDirectories() error {
@@ -261,6 +359,20 @@ func extractCredentials(bundlePath string) (*Credentials, error) {
 	return &creds, nil
 }
 
+// installSecretProvider picks the credentials source for install: the
+// --secrets-from flag, then the manifest's secretsFrom hint, then the bundle's
+// credentials.json
+func installSecretProvider(bundlePath string, manifest *Manifest) (SecretProvider, error) {
+	spec := installSecretsFrom
+	if spec == "" {
+		spec = manifest.SecretsFrom
+	}
+	if spec == "" {
+		return &fileSecretProvider{path: filepath.Join(bundlePath, "credentials.json")}, nil
+	}
+	return parseSecretSource(spec, installPassphraseFile)
+}
+
 func writeCredentials(creds *Credentials) error {
 	// Write admin key
 	if err := os.WriteFile("/etc/convex/admin.key", []byte(creds.AdminKey), 0600); err != nil {
@@ -275,43 +387,101 @@ func writeCredentials(creds *Credentials) error {
 	return nil
 }
 
-func createEnvConfig() error {
-	envContent := `CONVEX_SITE_URL=http://localhost:3210
+// generateHostCredentials creates a unique instance secret and admin key for
+// this host. The instance name is taken from the flag, the bundle's admin key
+// or defaults to "convex"; the bundle's secrets are not used.
+func generateHostCredentials(bundlePath, instanceName string) (*Credentials, error) {
+	if instanceName == "" {
+		instanceName = "convex"
+		if bundleCreds, err := extractCredentials(bundlePath); err == nil {
+			instanceName = instanceNameFromAdminKey(bundleCreds.AdminKey)
+		}
+	}
+
+	secret, err := generateInstanceSecret()
+	if err != nil {
+		return nil, err
+	}
+	adminKey, err := generateAdminKey(instanceName, secret)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := decryptAdminKey(adminKey, secret); err != nil {
+		return nil, fmt.Errorf("generated admin key does not decrypt under its instance secret: %w", err)
+	}
+
+	return &Credentials{AdminKey: adminKey, InstanceSecret: secret}, nil
+}
+
+// writeCredentialsFile writes credentials in the credentials.json format
+func writeCredentialsFile(path string, creds *Credentials) error {
+	data, err := json.MarshalIndent(creds, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write credentials to %s: %w", path, err)
+	}
+	return nil
+}
+
+// readInstalledCredentials reads the admin key and instance secret from /etc/convex
+func readInstalledCredentials() (*Credentials, error) {
+	adminKeyBytes, err := os.ReadFile("/etc/convex/admin.key")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read admin key: %w", err)
+	}
+
+	instanceSecretBytes, err := os.ReadFile("/etc/convex/instance.secret")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read instance secret: %w", err)
+	}
+
+	return &Credentials{
+		AdminKey:       string(adminKeyBytes),
+		InstanceSecret: string(instanceSecretBytes),
+	}, nil
+}
+
+// instanceNameFromAdminKey extracts the instance name from an admin key
+// (format: instanceName|base64data)
+func instanceNameFromAdminKey(adminKey string) string {
+	if idx := strings.Index(adminKey, "|"); idx > 0 {
+		return adminKey[:idx]
+	}
+	return "convex"
+}
+
+// envConfigContent is the /etc/convex/convex.env written by install
+const envConfigContent = `CONVEX_SITE_URL=http://localhost:3210
 CONVEX_LOCAL_STORAGE=/var/lib/convex/data
 CONVEX_ADMIN_KEY_FILE=/etc/convex/admin.key
 CONVEX_INSTANCE_SECRET_FILE=/etc/convex/instance.secret
 `
-	return os.WriteFile("/etc/convex/convex.env", []byte(envContent), 0644)
+
+func createEnvConfig() error {
+	return os.WriteFile("/etc/convex/convex.env", []byte(envConfigContent), 0644)
 }
 
 func installSystemdService() error {
-	// Read credentials to get the instance name and secret
-	creds, err := extractCredentials("/var/lib/convex")
+	// Read the installed credentials to get the instance name and secret
+	creds, err := readInstalledCredentials()
 	if err != nil {
-		// Fall back to reading from /etc/convex if bundle path doesn't work
-		adminKeyBytes, readErr := os.ReadFile("/etc/convex/admin.key")
-		if readErr != nil {
-			return fmt.Errorf("failed to read admin key: %w", readErr)
-		}
-		adminKey := string(adminKeyBytes)
-		
-		instanceSecretBytes, readErr := os.ReadFile("/etc/convex/instance.secret")
-		if readErr != nil {
-			return fmt.Errorf("failed to read instance secret: %w", readErr)
-		}
-		creds = &Credentials{
-			AdminKey:       adminKey,
-			InstanceSecret: string(instanceSecretBytes),
-		}
+		return err
 	}
 
-	// Extract instance name from admin key (format: instanceName|base64data)
-	instanceName := "convex"
-	if idx := strings.Index(creds.AdminKey, "|"); idx > 0 {
-		instanceName = creds.AdminKey[:idx]
+	if err := os.WriteFile("/etc/systemd/system/convex-backend.service", []byte(renderSystemdUnit(creds)), 0644); err != nil {
+		return err
 	}
 
-	serviceContent := fmt.Sprintf(`[Unit]
+	return daemonReload()
+}
+
+// renderSystemdUnit returns the convex-backend unit for the given credentials
+func renderSystemdUnit(creds *Credentials) string {
+	instanceName := instanceNameFromAdminKey(creds.AdminKey)
+
+	return fmt.Sprintf(`[Unit]
 Description=Convex Backend
 After=network.target
 
@@ -325,55 +495,26 @@ RestartSec=5
 [Install]
 WantedBy=multi-user.target
 `, instanceName, creds.InstanceSecret)
-	if err := os.WriteFile("/etc/systemd/system/convex-backend.service", []byte(serviceContent), 0644); err != nil {
-		return err
-	}
-
-	// Reload systemd
-	cmd := exec.Command("systemctl", "daemon-reload")
-	return cmd.Run()
 }
 
 func startService() error {
 	// Enable service
-	if err := exec.Command("systemctl", "enable", "convex-backend").Run(); err != nil {
+	if err := backendService.enable(false); err != nil {
 		return fmt.Errorf("failed to enable service: %w", err)
 	}
 
 	// Start service
-	if err := exec.Command("systemctl", "start", "convex-backend").Run(); err != nil {
+	if err := backendService.start(); err != nil {
 		return fmt.Errorf("failed to start service: %w", err)
 	}
 
 	return nil
 }
 
-func waitForHealth(url string, timeout time.Duration) error {
-	client := &http.Client{
-		Timeout: 2 * time.Second,
-	}
-
-	deadline := time.Now().Add(timeout)
-	for time.Now().Before(deadline) {
-		resp, err := client.Get(url + "/version")
-		if err == nil {
-			resp.Body.Close()
-			if resp.StatusCode == http.StatusOK {
-				return nil
-			}
-		}
-		time.Sleep(1 * time.Second)
-	}
-
-	return fmt.Errorf("backend did not become healthy within %v", timeout)
-}
-
 func showServiceLogs() {
 	printError("Recent service logs:")
-	cmd := exec.Command("journalctl", "-u", "convex-backend", "-n", "20", "--no-pager")
-	cmd.Stdout = os.Stderr
-	cmd.Stderr = os.Stderr
-	cmd.Run()
+	output, _ := exec.Command("journalctl", "-u", "convex-backend", "-n", "20", "--no-pager").CombinedOutput()
+	fmt.Fprint(os.Stderr, redactKnownSecrets(string(output)))
 }
 
 func readManifest(path string) (*Manifest, error) {
@@ -382,12 +523,8 @@ func readManifest(path string) (*Manifest, error) {
 		return nil, err
 	}
 
-	var manifest Manifest
-	if err := json.Unmarshal(data, &manifest); err != nil {
-		return nil, err
-	}
-
-	return &manifest, nil
+	manifest, _, err := parseManifest(data)
+	return manifest, err
 }
 
 func copyFile(src, dst string) error {
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerateHostCredentials(t *testing.T) {
+	bundle := t.TempDir()
+	os.WriteFile(filepath.Join(bundle, "credentials.json"), []byte(`{"adminKey":"shared|abcdef","instanceSecret":"0123abcd"}`), 0644)
+
+	creds, err := generateHostCredentials(bundle, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if instanceNameFromAdminKey(creds.AdminKey) != "shared" {
+		t.Errorf("expected the bundle's instance name, got %s", creds.AdminKey)
+	}
+	if creds.InstanceSecret == "0123abcd" || creds.AdminKey == "shared|abcdef" {
+		t.Error("bundle credentials were reused")
+	}
+
+	other, err := generateHostCredentials(bundle, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.InstanceSecret == creds.InstanceSecret {
+		t.Error("two installs generated the same instance secret")
+	}
+
+	named, err := generateHostCredentials(t.TempDir(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if instanceNameFromAdminKey(named.AdminKey) != "convex" {
+		t.Errorf("expected default instance name, got %s", named.AdminKey)
+	}
+}
+
+func TestWriteCredentialsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "creds.json")
+	if err := writeCredentialsFile(path, &Credentials{AdminKey: "a|b", InstanceSecret: "cd"}); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	data, _ := os.ReadFile(path)
+	var creds Credentials
+	if err := json.Unmarshal(data, &creds); err != nil || creds.AdminKey != "a|b" {
+		t.Errorf("unexpected file contents: %s", data)
+	}
+}
@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// ServiceActionOutput represents JSON output for start, stop, restart,
+// enable and disable
+type ServiceActionOutput struct {
+	Action         string              `json:"action"`
+	Unit           string              `json:"unit"`
+	Success        bool                `json:"success"`
+	Error          string              `json:"error,omitempty"`
+	ServiceStatus  string              `json:"serviceStatus"`
+	ServiceEnabled bool                `json:"serviceEnabled"`
+	Health         string              `json:"health,omitempty"`
+	HealthChecks   []HealthCheckResult `json:"healthChecks,omitempty"`
+	DurationMs     int64               `json:"durationMs"`
+}
+
+var (
+	lifecycleWaitHealthy bool
+	lifecycleNow         bool
+)
+
+var startCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start the backend service",
+	Long: `Start the convex-backend service. With --wait-healthy, wait for the health
+checks to pass, as install and upgrade do.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runServiceAction("start", backendService.start, lifecycleWaitHealthy)
+	},
+}
+
+var stopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the backend service",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runServiceAction("stop", backendService.stop, false)
+	},
+}
+
+var restartCmd = &cobra.Command{
+	Use:   "restart",
+	Short: "Restart the backend service",
+	Long: `Restart the convex-backend service. With --wait-healthy, wait for the health
+checks to pass, as install and upgrade do.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runServiceAction("restart", backendService.restart, lifecycleWaitHealthy)
+	},
+}
+
+var enableCmd = &cobra.Command{
+	Use:   "enable",
+	Short: "Start the backend service at boot",
+	Long: `Enable the convex-backend service at boot. With --now it is also started,
+and --wait-healthy waits for the health checks to pass.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runServiceAction("enable", func() error { return backendService.enable(lifecycleNow) }, lifecycleWaitHealthy && lifecycleNow)
+	},
+}
+
+var disableCmd = &cobra.Command{
+	Use:   "disable",
+	Short: "Do not start the backend service at boot",
+	Long:  `Disable the convex-backend service at boot. With --now it is also stopped.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runServiceAction("disable", func() error { return backendService.disable(lifecycleNow) }, false)
+	},
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{startCmd, stopCmd, restartCmd, enableCmd, disableCmd} {
+		rootCmd.AddCommand(cmd)
+	}
+
+	for _, cmd := range []*cobra.Command{startCmd, restartCmd, enableCmd} {
+		cmd.Flags().BoolVar(&lifecycleWaitHealthy, "wait-healthy", false, "Wait for the health checks to pass")
+		cmd.Flags().DurationVar(&flagHealthTimeout, "health-timeout", 0, "How long to wait for health checks to pass (default from health config, 30s)")
+	}
+	enableCmd.Flags().BoolVar(&lifecycleNow, "now", false, "Also start the service")
+	disableCmd.Flags().BoolVar(&lifecycleNow, "now", false, "Also stop the service")
+}
+
+// runServiceAction runs a lifecycle action, optionally waits for the backend
+// to become healthy, records the result in the audit log and prints it
+func runServiceAction(action string, fn func() error, waitHealthy bool) error {
+	if err := checkRoot(); err != nil {
+		return err
+	}
+	if err := checkSystemd(); err != nil {
+		return err
+	}
+
+	output := performServiceAction(action, fn, waitHealthy)
+	if err := auditServiceAction(output); err != nil {
+		printError("Failed to record %s in the audit log: %v", action, err)
+	}
+
+	if flagJSON {
+		if err := printJSON(output); err != nil {
+			return err
+		}
+	} else if output.Success {
+		printServiceAction(output)
+	} else {
+		showServiceLogs()
+	}
+
+	if !output.Success {
+		return fmt.Errorf("%s failed: %s", action, output.Error)
+	}
+	return nil
+}
+
+// performServiceAction runs the action through the service manager and
+// reports the resulting service state
+func performServiceAction(action string, fn func() error, waitHealthy bool) ServiceActionOutput {
+	started := time.Now()
+	output := ServiceActionOutput{Action: action, Unit: backendService.unit, Success: true}
+
+	if err := fn(); err != nil {
+		output.Success = false
+		output.Error = err.Error()
+	} else if waitHealthy {
+		if !flagJSON {
+			printInfo("Waiting for backend to be ready...")
+		}
+		if err := waitForHealth(); err != nil {
+			output.Success = false
+			output.Error = err.Error()
+		}
+		output.Health, output.HealthChecks = checkHealth()
+	}
+
+	output.ServiceStatus = backendService.activeState()
+	output.ServiceEnabled = backendService.isEnabled()
+	output.DurationMs = time.Since(started).Milliseconds()
+	return output
+}
+
+// auditServiceAction records the result of a lifecycle action in the audit log
+func auditServiceAction(output ServiceActionOutput) error {
+	details := map[string]string{"result": "success"}
+	if !output.Success {
+		details = map[string]string{"result": "failure", "error": output.Error}
+	}
+	return writeAuditLog("service."+output.Action, details)
+}
+
+func printServiceAction(output ServiceActionOutput) {
+	past := map[string]string{
+		"start":   "started",
+		"stop":    "stopped",
+		"restart": "restarted",
+		"enable":  "enabled",
+		"disable": "disabled",
+	}
+	boot := "disabled"
+	if output.ServiceEnabled {
+		boot = "enabled"
+	}
+	printSuccess("Service %s (%s, %s at boot) in %s", past[output.Action], output.ServiceStatus, boot,
+		time.Duration(output.DurationMs)*time.Millisecond)
+	if output.Health != "" {
+		printInfo("Health: %s", output.Health)
+	}
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// stubSystemctl replaces systemctl with a function that records its calls
+// and answers from the given outputs, keyed by the first argument
+func stubSystemctl(t *testing.T, outputs map[string]string, failures map[string]bool) *[]string {
+	t.Helper()
+	var calls []string
+	original := systemctl
+	systemctl = func(args ...string) ([]byte, error) {
+		calls = append(calls, strings.Join(args, " "))
+		if failures[args[0]] {
+			return []byte(outputs[args[0]]), errors.New("exit status 1")
+		}
+		return []byte(outputs[args[0]]), nil
+	}
+	t.Cleanup(func() { systemctl = original })
+	return &calls
+}
+
+func TestServiceManager(t *testing.T) {
+	calls := stubSystemctl(t, map[string]string{
+		"is-active":  "activating\n",
+		"is-enabled": "enabled\n",
+		"start":      "Job for convex-backend.service failed because the control process exited with error code.\n",
+	}, map[string]bool{"start": true})
+
+	err := backendService.start()
+	if err == nil || !strings.Contains(err.Error(), "Job for convex-backend.service failed") {
+		t.Errorf("expected systemctl's output in the error, got %v", err)
+	}
+	if err := backendService.enable(true); err != nil {
+		t.Fatal(err)
+	}
+	if err := exporterService.disable(false); err != nil {
+		t.Fatal(err)
+	}
+	if state := backendService.activeState(); state != "activating" {
+		t.Errorf("expected activating, got %q", state)
+	}
+	if !backendService.isEnabled() {
+		t.Error("expected the service to be enabled")
+	}
+
+	want := []string{
+		"start convex-backend",
+		"enable --now convex-backend",
+		"disable convex-backend-exporter",
+		"is-active convex-backend",
+		"is-enabled convex-backend",
+	}
+	if strings.Join(*calls, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected systemctl calls:\n%s", strings.Join(*calls, "\n"))
+	}
+}
+
+func TestActiveStateUnknown(t *testing.T) {
+	stubSystemctl(t, map[string]string{"is-active": "System has not been booted with systemd as init system (PID 1).\n"},
+		map[string]bool{"is-active": true})
+	if state := backendService.activeState(); state != "unknown" {
+		t.Errorf("expected unknown, got %q", state)
+	}
+}
+
+func TestPerformServiceAction(t *testing.T) {
+	stubSystemctl(t, map[string]string{
+		"is-active":  "inactive\n",
+		"is-enabled": "disabled\n",
+		"stop":       "Failed to stop convex-backend.service: Access denied\n",
+	}, map[string]bool{"stop": true})
+
+	output := performServiceAction("stop", backendService.stop, false)
+	if output.Success || !strings.Contains(output.Error, "Access denied") {
+		t.Errorf("expected a failed stop, got %+v", output)
+	}
+	if output.ServiceStatus != "inactive" || output.ServiceEnabled {
+		t.Errorf("expected the resulting state to be reported, got %+v", output)
+	}
+	if output.Health != "" {
+		t.Errorf("expected no health check without --wait-healthy, got %q", output.Health)
+	}
+
+	output = performServiceAction("disable", func() error { return backendService.disable(false) }, false)
+	if !output.Success || output.Action != "disable" || output.Unit != "convex-backend" {
+		t.Errorf("expected a successful disable, got %+v", output)
+	}
+}
+
+func TestServiceManagerProperties(t *testing.T) {
+	calls := stubSystemctl(t, map[string]string{"show": "MainPID=4242\nNRestarts=3\n"}, nil)
+
+	props := exporterService.properties("MainPID", "NRestarts")
+	if props["MainPID"] != "4242" || props["NRestarts"] != "3" {
+		t.Errorf("unexpected properties %v", props)
+	}
+	if want := "show convex-backend-exporter --property MainPID,NRestarts"; (*calls)[0] != want {
+		t.Errorf("expected %q, got %q", want, (*calls)[0])
+	}
+
+	stubSystemctl(t, nil, map[string]bool{"show": true})
+	if got := backendService.property("MainPID"); got != "" {
+		t.Errorf("expected an empty property when systemctl fails, got %q", got)
+	}
+}
+
+func TestAuditServiceAction(t *testing.T) {
+	old := auditLogPath
+	auditLogPath = filepath.Join(t.TempDir(), "audit.log")
+	defer func() { auditLogPath = old }()
+
+	if err := auditServiceAction(ServiceActionOutput{Action: "restart", Success: false, Error: "Access denied"}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(auditLogPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry AuditEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.Action != "service.restart" || entry.Details["result"] != "failure" || entry.Details["error"] != "Access denied" {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+}
@@ -18,6 +18,8 @@ type BackupInfo struct {
 	Size      int64  `json:"size"`
 	SizeHuman string `json:"sizeHuman"`
 	Reason    string `json:"reason"`
+	ToVersion string `json:"toVersion,omitempty"`
+	Outcome   string `json:"outcome,omitempty"`
 	Path      string `json:"path"`
 }
 
@@ -40,23 +42,63 @@ func init() {
 }
 
 func runListBackups(cmd *cobra.Command, args []string) error {
-	backupsDir := "/var/lib/convex/backups"
+	backups, err := listBackups("/var/lib/convex/backups")
+	if err != nil {
+		return err
+	}
+
+	var totalSize int64
+	for _, b := range backups {
+		totalSize += b.Size
+	}
+
+	output := ListBackupsOutput{
+		Backups:    backups,
+		TotalCount: len(backups),
+		TotalSize:  totalSize,
+	}
+
+	if flagJSON {
+		return printJSON(output)
+	}
+
+	// Human-readable output
+	if len(backups) == 0 {
+		fmt.Println("No backups found.")
+		return nil
+	}
+
+	fmt.Println("Available Backups")
+	fmt.Println("=================")
+	fmt.Println()
+	fmt.Printf("%-10s %-20s %-10s %s\n", "VERSION", "CREATED", "SIZE", "REASON")
+
+	for _, b := range backups {
+		created := b.Created
+		if t, err := time.Parse(time.RFC3339, b.Created); err == nil {
+			created = t.Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("v%-9s %-20s %-10s %s\n", b.Version, created, b.SizeHuman, b.Reason)
+	}
+
+	fmt.Println()
+	fmt.Printf("Total: %d backups (%s)\n", len(backups), humanizeBytes(totalSize))
+
+	return nil
+}
 
+// listBackups reads the backups in backupsDir, newest first. A missing
+// directory means there are no backups.
+func listBackups(backupsDir string) ([]BackupInfo, error) {
 	entries, err := os.ReadDir(backupsDir)
 	if err != nil {
 		if os.IsNotExist(err) {
-			if flagJSON {
-				return printJSON(ListBackupsOutput{Backups: []BackupInfo{}})
-			}
-			fmt.Println("No backups found.")
-			return nil
+			return []BackupInfo{}, nil
 		}
-		return fmt.Errorf("failed to read backups directory: %w", err)
+		return nil, fmt.Errorf("failed to read backups directory: %w", err)
 	}
 
-	var backups []BackupInfo
-	var totalSize int64
-
+	backups := []BackupInfo{}
 	for _, entry := range entries {
 		if !entry.IsDir() {
 			continue
@@ -75,16 +117,15 @@ func runListBackups(cmd *cobra.Command, args []string) error {
 			continue
 		}
 
-		// Calculate directory size
 		size := getDirSize(backupPath)
-		totalSize += size
-
 		backups = append(backups, BackupInfo{
 			Version:   meta.Version,
 			Created:   meta.Timestamp,
 			Size:      size,
 			SizeHuman: humanizeBytes(size),
 			Reason:    meta.Reason,
+			ToVersion: meta.ToVersion,
+			Outcome:   meta.Outcome,
 			Path:      backupPath,
 		})
 	}
@@ -96,39 +137,7 @@ func runListBackups(cmd *cobra.Command, args []string) error {
 		return ti.After(tj)
 	})
 
-	output := ListBackupsOutput{
-		Backups:    backups,
-		TotalCount: len(backups),
-		TotalSize:  totalSize,
-	}
-
-	if flagJSON {
-		return printJSON(output)
-	}
-
-	// Human-readable output
-	if len(backups) == 0 {
-		fmt.Println("No backups found.")
-		return nil
-	}
-
-	fmt.Println("Available Backups")
-	fmt.Println("=================")
-	fmt.Println()
-	fmt.Printf("%-10s %-20s %-10s %s\n", "VERSION", "CREATED", "SIZE", "REASON")
-
-	for _, b := range backups {
-		created := b.Created
-		if t, err := time.Parse(time.RFC3339, b.Created); err == nil {
-			created = t.Format("2006-01-02 15:04:05")
-		}
-		fmt.Printf("v%-9s %-20s %-10s %s\n", b.Version, created, b.SizeHuman, b.Reason)
-	}
-
-	fmt.Println()
-	fmt.Printf("Total: %d backups (%s)\n", len(backups), humanizeBytes(totalSize))
-
-	return nil
+	return backups, nil
 }
 
 func getDirSize(path string) int64 {
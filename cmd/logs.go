@@ -0,0 +1,328 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Normalized log levels, from most to least severe
+var logLevels = []string{"error", "warn", "info", "debug", "trace"}
+
+// LogEntry is one normalized line of the backend's journal
+type LogEntry struct {
+	Time    string `json:"time"`
+	Level   string `json:"level"`
+	Message string `json:"message"`
+	PID     int    `json:"pid,omitempty"`
+}
+
+// journalEntry holds the journalctl -o json fields we use
+type journalEntry struct {
+	Message   json.RawMessage `json:"MESSAGE"`
+	Timestamp string          `json:"__REALTIME_TIMESTAMP"`
+	Priority  string          `json:"PRIORITY"`
+	PID       string          `json:"_PID"`
+}
+
+var (
+	logsFollow bool
+	logsSince  string
+	logsUntil  string
+	logsLines  int
+	logsLevel  string
+	logsGrep   string
+	logsOutput string
+)
+
+var (
+	ansiColorPattern = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	logLevelPattern  = regexp.MustCompile(`(?i)^(?:level=)?(trace|debug|info|warn|warning|error|fatal|panic)$`)
+)
+
+var logsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Show the backend's service logs",
+	Long: `Show the convex-backend service logs from the systemd journal.
+
+Log levels are normalized to error, warn, info, debug and trace, taken from the
+backend's own log line where present and from the journal priority otherwise.
+--level shows that level and anything more severe. --grep filters messages
+with a regular expression. The installed admin key and instance secret are
+redacted, as are --instance-secret arguments and admin keys found by pattern.
+Without read access to the credentials (as a non-root user) only the
+pattern-based redaction applies.
+
+--since and --until accept anything journalctl does, e.g. "1 hour ago" or
+"2024-01-15 10:00". With --output the selected window is written to a file,
+e.g. to attach to a support ticket. With --json each entry is printed as one
+JSON object per line.`,
+	Args: cobra.NoArgs,
+	RunE: runLogs,
+}
+
+func init() {
+	rootCmd.AddCommand(logsCmd)
+	logsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "Keep printing new log lines")
+	logsCmd.Flags().StringVar(&logsSince, "since", "", "Show entries since this time")
+	logsCmd.Flags().StringVar(&logsUntil, "until", "", "Show entries until this time")
+	logsCmd.Flags().IntVarP(&logsLines, "lines", "n", 100, "Number of most recent entries to show (0 for all)")
+	logsCmd.Flags().StringVar(&logsLevel, "level", "", "Minimum level: error, warn, info, debug or trace")
+	logsCmd.Flags().StringVar(&logsGrep, "grep", "", "Only show messages matching this regular expression")
+	logsCmd.Flags().StringVarP(&logsOutput, "output", "o", "", "Write the entries to this file instead of stdout")
+}
+
+func runLogs(cmd *cobra.Command, args []string) error {
+	filter, err := newLogFilter(logsLevel, logsGrep)
+	if err != nil {
+		return err
+	}
+	if logsFollow && logsOutput != "" {
+		return fmt.Errorf("--follow cannot be combined with --output")
+	}
+
+	// Without an explicit --lines, a time window shows everything in it
+	lines := logsLines
+	if (logsSince != "" || logsUntil != "") && !cmd.Flags().Changed("lines") {
+		lines = 0
+	}
+
+	out := io.Writer(os.Stdout)
+	if logsOutput != "" {
+		f, err := os.OpenFile(logsOutput, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", logsOutput, err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	journalArgs := []string{"-u", "convex-backend", "-o", "json", "--no-pager"}
+	if logsSince != "" {
+		journalArgs = append(journalArgs, "--since", logsSince)
+	}
+	if logsUntil != "" {
+		journalArgs = append(journalArgs, "--until", logsUntil)
+	}
+	// Filtering happens here, so only let journalctl limit unfiltered output
+	if lines > 0 && (logsFollow || filter.empty()) {
+		journalArgs = append(journalArgs, "-n", strconv.Itoa(lines))
+	}
+	if logsFollow {
+		journalArgs = append(journalArgs, "-f")
+	}
+
+	journal := exec.Command("journalctl", journalArgs...)
+	journal.Stderr = os.Stderr
+	stdout, err := journal.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := journal.Start(); err != nil {
+		return fmt.Errorf("failed to run journalctl: %w", err)
+	}
+	// Stop journalctl if we return early, e.g. when stdout is closed
+	defer journal.Process.Kill()
+
+	redactor := newSecretRedactor()
+	if redactor.err != nil {
+		printError("Warning: cannot read the installed credentials (%v); only values shaped like secrets will be redacted", redactor.err)
+	}
+	var tail []LogEntry
+	count := 0
+	emit := func(entry LogEntry) error {
+		count++
+		return writeLogEntry(out, entry, flagJSON)
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		entry, ok := readJournalEntry(scanner.Bytes(), filter, redactor)
+		if !ok {
+			continue
+		}
+
+		if logsFollow || lines == 0 {
+			if err := emit(entry); err != nil {
+				return err
+			}
+			continue
+		}
+		tail = append(tail, entry)
+		if len(tail) > lines {
+			tail = tail[1:]
+		}
+	}
+	for _, entry := range tail {
+		if err := emit(entry); err != nil {
+			return err
+		}
+	}
+
+	if err := journal.Wait(); err != nil {
+		return fmt.Errorf("journalctl failed: %w", err)
+	}
+
+	if logsOutput != "" && !flagQuiet {
+		fmt.Fprintf(os.Stderr, "Wrote %d log entries to %s\n", count, logsOutput)
+	}
+	return nil
+}
+
+// parseJournalEntry converts a journalctl -o json line to a LogEntry
+func parseJournalEntry(line []byte) (LogEntry, bool) {
+	var je journalEntry
+	if err := json.Unmarshal(line, &je); err != nil {
+		return LogEntry{}, false
+	}
+
+	message := ansiColorPattern.ReplaceAllString(journalMessage(je.Message), "")
+	entry := LogEntry{Message: message}
+	if usec, err := strconv.ParseInt(je.Timestamp, 10, 64); err == nil {
+		entry.Time = time.UnixMicro(usec).UTC().Format(time.RFC3339Nano)
+	}
+	entry.PID, _ = strconv.Atoi(je.PID)
+	entry.Level = messageLogLevel(message)
+	if entry.Level == "" {
+		entry.Level = priorityLogLevel(je.Priority)
+	}
+	return entry, true
+}
+
+// journalMessage decodes MESSAGE, which journald emits as an array of bytes
+// when it is not valid UTF-8
+func journalMessage(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	var b []byte
+	var ints []int
+	if err := json.Unmarshal(raw, &ints); err == nil {
+		for _, i := range ints {
+			b = append(b, byte(i))
+		}
+	}
+	return string(b)
+}
+
+// messageLogLevel finds the level the backend printed in the first fields of
+// its log line, e.g. "2024-01-15T10:00:00.123Z  INFO convex::...: message"
+func messageLogLevel(message string) string {
+	fields := strings.Fields(message)
+	if len(fields) > 3 {
+		fields = fields[:3]
+	}
+	for _, field := range fields {
+		if m := logLevelPattern.FindStringSubmatch(strings.Trim(field, "[]:")); m != nil {
+			switch level := strings.ToLower(m[1]); level {
+			case "warning":
+				return "warn"
+			case "fatal", "panic":
+				return "error"
+			default:
+				return level
+			}
+		}
+	}
+	return ""
+}
+
+// priorityLogLevel maps a syslog priority to a level
+func priorityLogLevel(priority string) string {
+	switch p, _ := strconv.Atoi(priority); {
+	case priority == "":
+		return "info"
+	case p <= 3:
+		return "error"
+	case p == 4:
+		return "warn"
+	case p <= 6:
+		return "info"
+	default:
+		return "debug"
+	}
+}
+
+// logFilter selects entries by minimum level and message pattern
+type logFilter struct {
+	maxRank int
+	pattern *regexp.Regexp
+}
+
+func newLogFilter(level, grep string) (*logFilter, error) {
+	f := &logFilter{maxRank: len(logLevels) - 1}
+	if level != "" {
+		f.maxRank = logLevelRank(strings.ToLower(level))
+		if f.maxRank < 0 {
+			return nil, fmt.Errorf("unknown level %q (expected %s)", level, strings.Join(logLevels, ", "))
+		}
+	}
+	if grep != "" {
+		pattern, err := regexp.Compile(grep)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --grep pattern: %w", err)
+		}
+		f.pattern = pattern
+	}
+	return f, nil
+}
+
+func (f *logFilter) empty() bool {
+	return f.maxRank == len(logLevels)-1 && f.pattern == nil
+}
+
+// readJournalEntry parses one journal line, redacts it and applies the
+// filter. Redaction comes first so --grep cannot be used to probe for secrets.
+func readJournalEntry(line []byte, filter *logFilter, redactor *secretRedactor) (LogEntry, bool) {
+	entry, ok := parseJournalEntry(line)
+	if !ok {
+		return entry, false
+	}
+	entry.Message = redactor.Replace(entry.Message)
+	return entry, filter.match(entry)
+}
+
+func (f *logFilter) match(entry LogEntry) bool {
+	if logLevelRank(entry.Level) > f.maxRank {
+		return false
+	}
+	return f.pattern == nil || f.pattern.MatchString(entry.Message)
+}
+
+func logLevelRank(level string) int {
+	for i, l := range logLevels {
+		if l == level {
+			return i
+		}
+	}
+	return -1
+}
+
+func writeLogEntry(w io.Writer, entry LogEntry, asJSON bool) error {
+	if asJSON {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	}
+
+	ts := entry.Time
+	if t, err := time.Parse(time.RFC3339Nano, entry.Time); err == nil {
+		ts = t.Local().Format("2006-01-02 15:04:05")
+	}
+	_, err := fmt.Fprintf(w, "%s %-5s %s\n", ts, strings.ToUpper(entry.Level), entry.Message)
+	return err
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseJournalEntry(t *testing.T) {
+	tests := []struct {
+		line    string
+		level   string
+		message string
+	}{
+		{`{"MESSAGE":"2025-01-15T10:00:00.123Z  INFO convex_backend: listening on 0.0.0.0:3210","__REALTIME_TIMESTAMP":"1736935200123456","PRIORITY":"6","_PID":"4242"}`,
+			"info", "2025-01-15T10:00:00.123Z  INFO convex_backend: listening on 0.0.0.0:3210"},
+		{`{"MESSAGE":"\u001b[2m2025-01-15T10:00:00Z\u001b[0m \u001b[33m WARN\u001b[0m slow query","PRIORITY":"6"}`,
+			"warn", "2025-01-15T10:00:00Z  WARN slow query"},
+		{`{"MESSAGE":"[ERROR] database locked","PRIORITY":"6"}`, "error", "[ERROR] database locked"},
+		{`{"MESSAGE":"Started Convex Backend.","PRIORITY":"6"}`, "info", "Started Convex Backend."},
+		{`{"MESSAGE":"Main process exited, code=exited, status=1/FAILURE","PRIORITY":"3"}`, "error", "Main process exited, code=exited, status=1/FAILURE"},
+		{`{"MESSAGE":[104,105,255],"PRIORITY":"4"}`, "warn", "hi\xff"},
+	}
+
+	for _, tt := range tests {
+		entry, ok := parseJournalEntry([]byte(tt.line))
+		if !ok {
+			t.Errorf("failed to parse %s", tt.line)
+			continue
+		}
+		if entry.Level != tt.level || entry.Message != tt.message {
+			t.Errorf("parseJournalEntry(%s) = %q %q, want %q %q", tt.line, entry.Level, entry.Message, tt.level, tt.message)
+		}
+	}
+
+	entry, _ := parseJournalEntry([]byte(tests[0].line))
+	if entry.Time != "2025-01-15T10:00:00.123456Z" || entry.PID != 4242 {
+		t.Errorf("unexpected time or pid: %+v", entry)
+	}
+
+	if _, ok := parseJournalEntry([]byte("-- No entries --")); ok {
+		t.Error("expected a non-JSON line to be skipped")
+	}
+}
+
+func TestLogFilter(t *testing.T) {
+	filter, err := newLogFilter("warn", "slow|locked")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		entry LogEntry
+		want  bool
+	}{
+		{LogEntry{Level: "error", Message: "database locked"}, true},
+		{LogEntry{Level: "warn", Message: "slow query"}, true},
+		{LogEntry{Level: "info", Message: "slow query"}, false},
+		{LogEntry{Level: "error", Message: "panic"}, false},
+	}
+	for _, tt := range tests {
+		if got := filter.match(tt.entry); got != tt.want {
+			t.Errorf("match(%+v) = %v, want %v", tt.entry, got, tt.want)
+		}
+	}
+
+	if _, err := newLogFilter("verbose", ""); err == nil {
+		t.Error("expected an error for an unknown level")
+	}
+	if _, err := newLogFilter("", "("); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+	if f, _ := newLogFilter("", ""); !f.empty() {
+		t.Error("expected an empty filter")
+	}
+}
+
+func TestWriteLogEntry_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	entry := LogEntry{Time: "2025-01-15T10:00:00Z", Level: "warn", Message: "slow query", PID: 42}
+	if err := writeLogEntry(&buf, entry, true); err != nil {
+		t.Fatal(err)
+	}
+
+	var got LogEntry
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil || got != entry {
+		t.Errorf("unexpected JSON line %q", buf.String())
+	}
+}
+
+func TestLogRedactionWithoutCredentials(t *testing.T) {
+	entry, ok := parseJournalEntry([]byte(`{"MESSAGE":"Starting convex-backend --instance-name app --instance-secret 4361726c6f73204d61726961 --port 3210","__REALTIME_TIMESTAMP":"1736935200123456","PRIORITY":"6"}`))
+	if !ok {
+		t.Fatal("failed to parse entry")
+	}
+
+	// What a non-root reader gets: the credential files cannot be read
+	redactor := &secretRedactor{known: strings.NewReplacer(), err: errors.New("permission denied")}
+	got := redactor.Replace(entry.Message)
+	if strings.Contains(got, "4361726c6f73204d") || !strings.Contains(got, "--instance-secret ****6961 --port 3210") {
+		t.Errorf("expected the instance secret to be scrubbed by pattern, got %q", got)
+	}
+}
+
+func TestReadJournalEntry_FiltersRedactedMessage(t *testing.T) {
+	line := []byte(`{"MESSAGE":"Starting convex-backend --instance-secret 4361726c6f73204d61726961 --port 3210","__REALTIME_TIMESTAMP":"1736935200123456","PRIORITY":"6"}`)
+	redactor := &secretRedactor{known: strings.NewReplacer(), err: errors.New("permission denied")}
+
+	// A pattern matching the secret must not reveal that it is there
+	probe, err := newLogFilter("", "secret 4361")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := readJournalEntry(line, probe, redactor); ok {
+		t.Error("expected --grep to match against the redacted message only")
+	}
+
+	filter, err := newLogFilter("", "secret \\*{4}6961")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, ok := readJournalEntry(line, filter, redactor)
+	if !ok || strings.Contains(entry.Message, "4361726c6f73204d") {
+		t.Errorf("expected the redacted entry to match, got %q (%v)", entry.Message, ok)
+	}
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// manifestSchemaVersion is the newest manifest schema this build understands.
+// Manifests without a schemaVersion field are treated as version 1.
+const manifestSchemaVersion = 1
+
+// Bundle types declared by the manifest "type" field. Full bundles ship a
+// backend binary and database; apps bundles only carry a functions package
+// that is pushed into the running backend.
+const (
+	bundleTypeFull = "full"
+	bundleTypeApps = "apps"
+)
+
+// knownManifestFields lists every manifest.json field this build understands
+var knownManifestFields = map[string]bool{
+	"schemaVersion": true,
+	"type":          true,
+	"name":          true,
+	"version":       true,
+	"appsVersion":   true,
+	"apps":          true,
+	"platform":      true,
+	"createdAt":     true,
+	"minOpsVersion": true,
+	"upgradeFrom":   true,
+	"health":        true,
+
+	"generateCredentials": true,
+	"secretsFrom":         true,
+}
+
+// gitDescribeSuffix matches the "-<commits>-g<hash>[-dirty]" suffix that
+// `git describe` appends to development builds
+var gitDescribeSuffix = regexp.MustCompile(`-\d+-g[0-9a-f]+(-dirty)?$|-dirty$`)
+
+// parseManifest decodes manifest.json and returns the names of any fields
+// this build does not understand, so callers can warn instead of silently
+// dropping them
+func parseManifest(data []byte) (*Manifest, []string, error) {
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, nil, err
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, nil, err
+	}
+
+	var unknown []string
+	for field := range raw {
+		if !knownManifestFields[field] {
+			unknown = append(unknown, field)
+		}
+	}
+	sort.Strings(unknown)
+
+	return &manifest, unknown, nil
+}
+
+// marshalManifest serializes manifest on top of the original manifest.json,
+// so fields this build does not understand survive the rewrite
+func marshalManifest(original []byte, manifest *Manifest) ([]byte, error) {
+	fields := map[string]json.RawMessage{}
+	if len(original) > 0 {
+		if err := json.Unmarshal(original, &fields); err != nil {
+			return nil, err
+		}
+	}
+	for field := range fields {
+		if knownManifestFields[field] {
+			delete(fields, field)
+		}
+	}
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+	var known map[string]json.RawMessage
+	if err := json.Unmarshal(data, &known); err != nil {
+		return nil, err
+	}
+	for field, value := range known {
+		fields[field] = value
+	}
+
+	return json.MarshalIndent(fields, "", "  ")
+}
+
+// effectiveSchemaVersion returns the manifest schema version, defaulting
+// legacy manifests to version 1
+func (m *Manifest) effectiveSchemaVersion() int {
+	if m.SchemaVersion == 0 {
+		return 1
+	}
+	return m.SchemaVersion
+}
+
+// isAppsOnly reports whether the bundle carries app deployments only
+func (m *Manifest) isAppsOnly() bool {
+	return m.Type == bundleTypeApps
+}
+
+// checkManifestCompatibility verifies that this build of convex-backend-ops
+// can handle the bundle described by the manifest
+func checkManifestCompatibility(m *Manifest) error {
+	if m.effectiveSchemaVersion() > manifestSchemaVersion {
+		return fmt.Errorf("bundle manifest uses schema version %d, but convex-backend-ops %s only supports up to version %d. Upgrade convex-backend-ops first",
+			m.SchemaVersion, Version, manifestSchemaVersion)
+	}
+
+	if m.MinOpsVersion == "" {
+		return nil
+	}
+
+	minVersion, err := parseVersion(m.MinOpsVersion)
+	if err != nil {
+		return fmt.Errorf("bundle manifest has invalid minOpsVersion: %w", err)
+	}
+
+	current, err := parseVersion(opsVersion())
+	if err != nil {
+		// Development builds have no comparable version
+		printInfo("Warning: bundle requires convex-backend-ops >= %s, cannot verify with version %q", minVersion, Version)
+		return nil
+	}
+
+	if current.compare(minVersion) < 0 {
+		return fmt.Errorf("bundle requires convex-backend-ops >= %s, but this is version %s. Upgrade convex-backend-ops first",
+			minVersion, current)
+	}
+
+	return nil
+}
+
+// opsVersion returns the running binary's version with any git describe
+// development suffix removed
+func opsVersion() string {
+	return gitDescribeSuffix.ReplaceAllString(Version, "")
+}
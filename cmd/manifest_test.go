@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseManifest_UnknownFields(t *testing.T) {
+	data := []byte(`{"name":"App","version":"1.0.0","apps":[],"platform":"linux-x64","createdAt":"2025-01-01T00:00:00Z","zeta":1,"alpha":{"x":true}}`)
+
+	manifest, unknown, err := parseManifest(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Name != "App" {
+		t.Errorf("unexpected name %q", manifest.Name)
+	}
+	if strings.Join(unknown, ",") != "alpha,zeta" {
+		t.Errorf("unexpected unknown fields: %v", unknown)
+	}
+}
+
+func TestCheckManifestCompatibility(t *testing.T) {
+	defer func(v string) { Version = v }(Version)
+
+	tests := []struct {
+		name     string
+		version  string
+		manifest Manifest
+		wantErr  string
+	}{
+		{"legacy manifest", "1.0.0", Manifest{}, ""},
+		{"current schema", "1.0.0", Manifest{SchemaVersion: manifestSchemaVersion}, ""},
+		{"future schema", "1.0.0", Manifest{SchemaVersion: manifestSchemaVersion + 1}, "schema version"},
+		{"ops new enough", "1.2.0", Manifest{MinOpsVersion: "1.2.0"}, ""},
+		{"ops too old", "1.1.9", Manifest{MinOpsVersion: "1.2.0"}, "requires convex-backend-ops >= 1.2.0"},
+		{"git describe build", "v1.2.0-4-gabc1234-dirty", Manifest{MinOpsVersion: "1.2.0"}, ""},
+		{"dev build", "dev", Manifest{MinOpsVersion: "9.0.0"}, ""},
+		{"invalid requirement", "1.0.0", Manifest{MinOpsVersion: "soon"}, "invalid minOpsVersion"},
+	}
+
+	flagQuiet = true
+	defer func() { flagQuiet = false }()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Version = tt.version
+			err := checkManifestCompatibility(&tt.manifest)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestMergeAppsManifest_KeepsBackendVersion(t *testing.T) {
+	current := &Manifest{Name: "App", Version: "1.5.0", Platform: "linux-x64", UpgradeFrom: []string{">=1.0.0"}}
+	next := &Manifest{Type: bundleTypeApps, Name: "App", Version: "2024.6.1", Apps: []string{"./app"}}
+
+	merged := mergeAppsManifest(current, next)
+	if merged.Version != "1.5.0" {
+		t.Errorf("backend version overwritten: got %q", merged.Version)
+	}
+	if merged.AppsVersion != "2024.6.1" {
+		t.Errorf("unexpected apps version %q", merged.AppsVersion)
+	}
+	if merged.Type != "" || merged.Platform != "linux-x64" {
+		t.Errorf("backend type or platform changed: %q %q", merged.Type, merged.Platform)
+	}
+	if strings.Join(merged.UpgradeFrom, ",") != ">=1.0.0" {
+		t.Errorf("upgrade constraints changed: %v", merged.UpgradeFrom)
+	}
+	if strings.Join(merged.Apps, ",") != "./app" {
+		t.Errorf("unexpected apps %v", merged.Apps)
+	}
+}
+
+func TestMarshalManifest_KeepsUnknownFields(t *testing.T) {
+	original := []byte(`{"name":"App","version":"1.0.0","apps":[],"platform":"linux-x64","createdAt":"2025-01-01T00:00:00Z","health":{"timeout":"30s"},"zeta":{"x":true}}`)
+	manifest, _, err := parseManifest(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest.AppsVersion = "2.0.0"
+	manifest.Health = nil
+
+	data, err := marshalManifest(original, manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	written, unknown, err := parseManifest(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(unknown, ",") != "zeta" {
+		t.Errorf("expected the unknown field to be kept, got %v", unknown)
+	}
+	if written.AppsVersion != "2.0.0" || written.Version != "1.0.0" {
+		t.Errorf("unexpected versions %q %q", written.Version, written.AppsVersion)
+	}
+	if written.Health != nil {
+		t.Errorf("expected cleared known field to be dropped, got %+v", written.Health)
+	}
+}
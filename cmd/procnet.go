@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// tcpListenState is the st column value of a listening socket in /proc/net/tcp
+const tcpListenState = "0A"
+
+// listeningPorts returns the TCP ports a process is listening on, found by
+// matching its socket file descriptors against /proc/net/tcp{,6}
+func listeningPorts(pid int) []int {
+	inodes := make(map[string]bool)
+	fdDir := fmt.Sprintf("/proc/%d/fd", pid)
+	entries, err := os.ReadDir(fdDir)
+	if err != nil {
+		return []int{}
+	}
+	for _, entry := range entries {
+		target, err := os.Readlink(filepath.Join(fdDir, entry.Name()))
+		if err == nil && strings.HasPrefix(target, "socket:[") {
+			inodes[strings.TrimSuffix(strings.TrimPrefix(target, "socket:["), "]")] = true
+		}
+	}
+
+	seen := make(map[int]bool)
+	ports := []int{}
+	for _, table := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		data, err := os.ReadFile(table)
+		if err != nil {
+			continue
+		}
+		for inode, port := range parseProcNetListeners(string(data)) {
+			if inodes[inode] && !seen[port] {
+				seen[port] = true
+				ports = append(ports, port)
+			}
+		}
+	}
+	sort.Ints(ports)
+	return ports
+}
+
+// parseProcNetListeners maps socket inodes to local ports for the listening
+// sockets in a /proc/net/tcp table
+func parseProcNetListeners(table string) map[string]int {
+	listeners := make(map[string]int)
+	for _, line := range strings.Split(table, "\n")[1:] {
+		fields := strings.Fields(line)
+		if len(fields) < 10 || fields[3] != tcpListenState {
+			continue
+		}
+		_, portHex, ok := strings.Cut(fields[1], ":")
+		if !ok {
+			continue
+		}
+		port, err := strconv.ParseInt(portHex, 16, 32)
+		if err != nil {
+			continue
+		}
+		listeners[fields[9]] = int(port)
+	}
+	return listeners
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// auditLogPath records every time a secret is revealed or replaced
+var auditLogPath = "/var/lib/convex/audit.log"
+
+// AuditEntry is a single line of the audit log
+type AuditEntry struct {
+	Time    string            `json:"time"`
+	Action  string            `json:"action"`
+	User    string            `json:"user"`
+	UID     int               `json:"uid"`
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// redactSecret hides all but the last four characters of a secret
+func redactSecret(secret string) string {
+	secret = strings.TrimSpace(secret)
+	if len(secret) < 12 {
+		return "****"
+	}
+	return "****" + secret[len(secret)-4:]
+}
+
+// redactAdminKey keeps the instance name of an admin key and redacts the rest
+func redactAdminKey(adminKey string) string {
+	adminKey = strings.TrimSpace(adminKey)
+	if idx := strings.Index(adminKey, "|"); idx > 0 {
+		return adminKey[:idx+1] + redactSecret(adminKey[idx+1:])
+	}
+	return redactSecret(adminKey)
+}
+
+// Secrets recognized by their shape rather than their value: the backend's
+// --instance-secret argument and <name>|<hex> admin keys
+var (
+	instanceSecretArgPattern = regexp.MustCompile(`(--instance-secret[= ]+)(\S+)`)
+	adminKeyPattern          = regexp.MustCompile(`([\w.-]+\|)([0-9a-fA-F]{20,})`)
+)
+
+// scrubSecretPatterns redacts secrets by shape, so that text is scrubbed even
+// when the installed credentials cannot be read or differ from the ones it
+// mentions, e.g. a previous instance secret in older logs
+func scrubSecretPatterns(text string) string {
+	text = instanceSecretArgPattern.ReplaceAllStringFunc(text, func(match string) string {
+		parts := instanceSecretArgPattern.FindStringSubmatch(match)
+		if strings.HasPrefix(parts[2], "****") {
+			return match
+		}
+		return parts[1] + redactSecret(parts[2])
+	})
+	return adminKeyPattern.ReplaceAllStringFunc(text, func(match string) string {
+		parts := adminKeyPattern.FindStringSubmatch(match)
+		return parts[1] + redactSecret(parts[2])
+	})
+}
+
+// redactKnownSecrets replaces the installed admin key and instance secret in
+// text that may echo them, such as service logs
+func redactKnownSecrets(text string) string {
+	return newSecretRedactor().Replace(text)
+}
+
+// secretRedactor redacts the installed admin key and instance secret, and
+// anything shaped like a secret, for redacting many lines without rereading
+// the credentials
+type secretRedactor struct {
+	known *strings.Replacer
+	// err is why the installed credentials could not be read, in which case
+	// only the shape-based scrub applies
+	err error
+}
+
+// newSecretRedactor reads the installed credentials once for redaction
+func newSecretRedactor() *secretRedactor {
+	creds, err := readInstalledCredentials()
+	if err != nil {
+		return &secretRedactor{known: strings.NewReplacer(), err: err}
+	}
+
+	var pairs []string
+	if adminKey := strings.TrimSpace(creds.AdminKey); adminKey != "" {
+		pairs = append(pairs, adminKey, redactAdminKey(adminKey))
+	}
+	if secret := strings.TrimSpace(creds.InstanceSecret); secret != "" {
+		pairs = append(pairs, secret, redactSecret(secret))
+	}
+	return &secretRedactor{known: strings.NewReplacer(pairs...)}
+}
+
+// Replace redacts the secrets in text
+func (r *secretRedactor) Replace(text string) string {
+	return scrubSecretPatterns(r.known.Replace(text))
+}
+
+// writeAuditLog appends an entry to the audit log
+func writeAuditLog(action string, details map[string]string) error {
+	user := os.Getenv("SUDO_USER")
+	if user == "" {
+		user = os.Getenv("USER")
+	}
+
+	entry := AuditEntry{
+		Time:    time.Now().UTC().Format(time.RFC3339),
+		Action:  action,
+		User:    user,
+		UID:     os.Getuid(),
+		Details: details,
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(auditLogPath), 0755); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	f, err := os.OpenFile(auditLogPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRedactAdminKey(t *testing.T) {
+	tests := []struct {
+		key, want string
+	}{
+		{"convex-self-hosted|01f2a3b4c5d6e7f8a9b0", "convex-self-hosted|****a9b0"},
+		{"0123456789abcdef", "****cdef"},
+		{"name|short", "name|****"},
+		{"", "****"},
+	}
+	for _, tt := range tests {
+		if got := redactAdminKey(tt.key); got != tt.want {
+			t.Errorf("redactAdminKey(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestRedactSecretHidesValue(t *testing.T) {
+	secret := "4361726c6f73204d61726961204a6f7365204c75697320416c62657274"
+	got := redactSecret(secret)
+	if strings.Contains(got, secret[:8]) || len(got) > 8 {
+		t.Errorf("redactSecret leaked the secret: %q", got)
+	}
+}
+
+func TestScrubSecretPatterns(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"backend --instance-secret 4361726c6f73204d61726961 --port 3210", "backend --instance-secret ****6961 --port 3210"},
+		{"--instance-secret=4361726c6f73204d61726961", "--instance-secret=****6961"},
+		{"--instance-secret ****6961", "--instance-secret ****6961"},
+		{"key convex-self-hosted|01f2a3b4c5d6e7f8a9b0c1d2 rejected", "key convex-self-hosted|****c1d2 rejected"},
+		{"a|b and version 1.2.3", "a|b and version 1.2.3"},
+	}
+	for _, tt := range tests {
+		if got := scrubSecretPatterns(tt.in); got != tt.want {
+			t.Errorf("scrubSecretPatterns(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWriteAuditLog(t *testing.T) {
+	old := auditLogPath
+	auditLogPath = filepath.Join(t.TempDir(), "logs", "audit.log")
+	defer func() { auditLogPath = old }()
+
+	for i := 0; i < 2; i++ {
+		if err := writeAuditLog("credentials.reveal", map[string]string{"format": "env"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(auditLogPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 audit entries, got %d", len(lines))
+	}
+
+	var entry AuditEntry
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.Action != "credentials.reveal" || entry.Details["format"] != "env" || entry.Time == "" {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+
+	info, _ := os.Stat(auditLogPath)
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("audit log mode = %v, want 0600", info.Mode().Perm())
+	}
+}
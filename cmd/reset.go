@@ -4,7 +4,6 @@ import (
 	"bufio"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 
@@ -27,7 +26,7 @@ func init() {
 	rootCmd.AddCommand(resetCmd)
 }
 
-func runReset(cmd *cobra.Command, args []string) error {
+func runReset(cmd *cobra.Command, args []string) (retErr error) {
 	// Pre-flight checks
 	if err := checkRoot(); err != nil {
 		return err
@@ -60,11 +59,21 @@ func runReset(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	hooks := &hookContext{Operation: "reset"}
+	if manifest, err := readManifest("/var/lib/convex/manifest.json"); err == nil {
+		hooks.FromVersion = manifest.Version
+		hooks.ToVersion = manifest.Version
+	}
+	if err := hooks.runPre(); err != nil {
+		return fmt.Errorf("pre-reset hook failed: %w", err)
+	}
+	defer func() { hooks.runPost(retErr) }()
+
 	printInfo("Performing factory reset...")
 
 	// Stop service
 	printInfo("Stopping service...")
-	if err := exec.Command("systemctl", "stop", "convex-backend").Run(); err != nil {
+	if err := backendService.stop(); err != nil {
 		return fmt.Errorf("failed to stop service: %w", err)
 	}
 
@@ -92,7 +101,7 @@ func runReset(cmd *cobra.Command, args []string) error {
 
 	// Start service
 	printInfo("Starting service...")
-	if err := exec.Command("systemctl", "start", "convex-backend").Run(); err != nil {
+	if err := backendService.start(); err != nil {
 		return fmt.Errorf("failed to start service: %w", err)
 	}
 
@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"sort"
 	"time"
@@ -25,9 +24,10 @@ If a version is specified, rolls back to that specific version.`,
 
 func init() {
 	rootCmd.AddCommand(rollbackCmd)
+	rollbackCmd.Flags().DurationVar(&flagHealthTimeout, "health-timeout", 0, "How long to wait for health checks to pass (default from health config, 30s)")
 }
 
-func runRollback(cmd *cobra.Command, args []string) error {
+func runRollback(cmd *cobra.Command, args []string) (retErr error) {
 	// Pre-flight checks
 	if err := checkRoot(); err != nil {
 		return err
@@ -57,27 +57,41 @@ func runRollback(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	hooks := &hookContext{
+		Operation: "rollback",
+		ToVersion: backupVersion,
+		BackupDir: backupDir,
+	}
+	if current, err := readManifest("/var/lib/convex/manifest.json"); err == nil {
+		hooks.FromVersion = current.Version
+	}
+	if err := hooks.runPre(); err != nil {
+		return fmt.Errorf("pre-rollback hook failed: %w", err)
+	}
+	defer func() { hooks.runPost(retErr) }()
+
 	printInfo("Rolling back to v%s...", backupVersion)
 
 	// Stop service
 	printInfo("Stopping service...")
-	exec.Command("systemctl", "stop", "convex-backend").Run()
+	backendService.stop()
 
 	// Perform rollback
 	printInfo("Restoring from backup...")
 	if err := restoreFromBackup(backupDir); err != nil {
 		return fmt.Errorf("failed to restore from backup: %w", err)
 	}
+	recordInstalledFilesOrWarn("rollback")
 
 	// Start service
 	printInfo("Starting service...")
-	if err := exec.Command("systemctl", "start", "convex-backend").Run(); err != nil {
+	if err := backendService.start(); err != nil {
 		return fmt.Errorf("failed to start service: %w", err)
 	}
 
 	// Health check
 	printInfo("Waiting for backend to be ready...")
-	if err := waitForHealth("http://localhost:3210", 30*time.Second); err != nil {
+	if err := waitForHealth(); err != nil {
 		showServiceLogs()
 		return fmt.Errorf("health check failed after rollback: %w", err)
 	}
@@ -118,6 +132,10 @@ func findMostRecentBackup() (string, string, error) {
 		if err := json.Unmarshal(data, &meta); err != nil {
 			continue
 		}
+		// Manual backups are restored by name only
+		if meta.Reason == backupReasonManual {
+			continue
+		}
 
 		ts, err := time.Parse(time.RFC3339, meta.Timestamp)
 		if err != nil {
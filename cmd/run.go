@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// RunOutput represents JSON output for the run command
+type RunOutput struct {
+	Path         string          `json:"path"`
+	Status       string          `json:"status"`
+	Value        json.RawMessage `json:"value,omitempty"`
+	ErrorMessage string          `json:"errorMessage,omitempty"`
+	LogLines     []string        `json:"logLines"`
+}
+
+var runArgs string
+
+var runCmd = &cobra.Command{
+	Use:   "run <functionPath>",
+	Short: "Run a query, mutation or action",
+	Long: `Run a Convex function on the installed backend, e.g. for maintenance runbooks.
+
+The function path has the form module:function (for example admin:reindex).
+Arguments are given as a JSON object with --args. The function runs with admin
+privileges using /etc/convex/admin.key, so internal functions can be called too.
+
+The return value is printed as JSON and log lines go to stderr. Exits with a
+non-zero status if the function throws.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRun,
+}
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+	runCmd.Flags().StringVarP(&runArgs, "args", "a", "{}", "Function arguments as a JSON object")
+}
+
+func runRun(cmd *cobra.Command, args []string) error {
+	var fnArgs map[string]interface{}
+	if err := json.Unmarshal([]byte(runArgs), &fnArgs); err != nil || fnArgs == nil {
+		return fmt.Errorf("--args must be a JSON object, e.g. '{\"limit\": 10}'")
+	}
+
+	client, err := newAdminClient()
+	if err != nil {
+		return err
+	}
+
+	result, err := client.callFunction("/api/function", args[0], fnArgs)
+	if err != nil {
+		return fmt.Errorf("failed to run %s: %w", args[0], err)
+	}
+
+	output := RunOutput{
+		Path:         args[0],
+		Status:       result.Status,
+		Value:        result.Value,
+		ErrorMessage: result.ErrorMessage,
+		LogLines:     result.LogLines,
+	}
+	if output.LogLines == nil {
+		output.LogLines = []string{}
+	}
+
+	if flagJSON {
+		if err := printJSON(output); err != nil {
+			return err
+		}
+	} else {
+		for _, line := range output.LogLines {
+			fmt.Fprintln(os.Stderr, line)
+		}
+		if result.Status == "success" {
+			printFunctionValue(result.Value)
+		}
+	}
+
+	if result.Status != "success" {
+		return fmt.Errorf("%s failed: %s", args[0], result.ErrorMessage)
+	}
+	return nil
+}
+
+// printFunctionValue pretty-prints a function's JSON return value
+func printFunctionValue(value json.RawMessage) {
+	if len(value) == 0 {
+		fmt.Println("null")
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, value, "", "  "); err != nil {
+		fmt.Println(string(value))
+		return
+	}
+	fmt.Println(buf.String())
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRunFunction(t *testing.T) {
+	_, server := newStubBackend(t, "test|key")
+	useStubBackend(t, server, "test|key")
+	defer func() { runArgs = "{}" }()
+
+	runArgs = `{"limit": 10}`
+	if err := runRun(runCmd, []string{"admin:echo"}); err != nil {
+		t.Fatal(err)
+	}
+
+	err := runRun(runCmd, []string{"admin:fail"})
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("expected function error, got %v", err)
+	}
+
+	err = runRun(runCmd, []string{"admin:missing"})
+	if err == nil || !strings.Contains(err.Error(), "Could not find function") {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+func TestRunFunction_InvalidArgs(t *testing.T) {
+	defer func() { runArgs = "{}" }()
+
+	for _, args := range []string{"[1,2]", "not json", "null"} {
+		runArgs = args
+		if err := runRun(runCmd, []string{"admin:echo"}); err == nil || !strings.Contains(err.Error(), "JSON object") {
+			t.Errorf("args %q: expected JSON object error, got %v", args, err)
+		}
+	}
+}
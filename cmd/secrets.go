@@ -0,0 +1,393 @@
+package cmd
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SecretProvider supplies the admin key and instance secret from a source
+// outside the bundle
+type SecretProvider interface {
+	// Name describes the source for output, without revealing any secrets
+	Name() string
+	Load() (*Credentials, error)
+}
+
+// keystoreIterations is the PBKDF2 work factor for newly sealed keystores
+var keystoreIterations = 600000
+
+// parseSecretSource returns the provider for a --secrets-from value:
+//
+//	file:<path> or <path>   credentials.json file
+//	env[:<prefix>]          <prefix>ADMIN_KEY and <prefix>INSTANCE_SECRET (default prefix CONVEX_)
+//	systemd[:<name>]        systemd credential holding credentials.json (default name convex)
+//	keystore:<path>         encrypted keystore unlocked with the passphrase file
+//	vault:<url>             Vault KV secret read with VAULT_TOKEN
+func parseSecretSource(spec, passphraseFile string) (SecretProvider, error) {
+	scheme, value, hasValue := strings.Cut(spec, ":")
+
+	switch scheme {
+	case "file":
+		if value == "" {
+			return nil, fmt.Errorf("secret source %q needs a path", spec)
+		}
+		return &fileSecretProvider{path: value}, nil
+	case "env":
+		prefix := "CONVEX_"
+		if hasValue {
+			prefix = value
+		}
+		return &envSecretProvider{prefix: prefix}, nil
+	case "systemd":
+		name := "convex"
+		if value != "" {
+			name = value
+		}
+		return &systemdSecretProvider{name: name}, nil
+	case "keystore":
+		if value == "" {
+			return nil, fmt.Errorf("secret source %q needs a path", spec)
+		}
+		return &keystoreSecretProvider{path: value, passphraseFile: passphraseFile}, nil
+	case "vault":
+		if !strings.HasPrefix(value, "http://") && !strings.HasPrefix(value, "https://") {
+			return nil, fmt.Errorf("secret source %q needs an http(s) URL", spec)
+		}
+		return &vaultSecretProvider{url: value}, nil
+	}
+
+	if !hasValue || strings.HasPrefix(spec, "/") || strings.HasPrefix(spec, ".") {
+		return &fileSecretProvider{path: spec}, nil
+	}
+	return nil, fmt.Errorf("unknown secret source %q (expected file:, env, systemd, keystore: or vault:)", spec)
+}
+
+// validate checks that the credentials look like what the backend expects
+func (c *Credentials) validate() error {
+	if c.AdminKey == "" {
+		return fmt.Errorf("adminKey must not be empty")
+	}
+	if strings.Index(c.AdminKey, "|") <= 0 {
+		return fmt.Errorf("adminKey is not in the form <instanceName>|<key>")
+	}
+	if c.InstanceSecret == "" {
+		return fmt.Errorf("instanceSecret must not be empty")
+	}
+	if _, err := hex.DecodeString(c.InstanceSecret); err != nil {
+		return fmt.Errorf("instanceSecret is not hex-encoded")
+	}
+	return nil
+}
+
+// parseCredentialsJSON decodes and validates the credentials.json format
+func parseCredentialsJSON(data []byte) (*Credentials, error) {
+	var creds Credentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return nil, err
+	}
+	creds.AdminKey = strings.TrimSpace(creds.AdminKey)
+	creds.InstanceSecret = strings.TrimSpace(creds.InstanceSecret)
+	if err := creds.validate(); err != nil {
+		return nil, err
+	}
+	return &creds, nil
+}
+
+// fileSecretProvider reads a credentials.json file
+type fileSecretProvider struct {
+	path string
+}
+
+func (p *fileSecretProvider) Name() string {
+	return "file " + p.path
+}
+
+func (p *fileSecretProvider) Load() (*Credentials, error) {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return nil, err
+	}
+	creds, err := parseCredentialsJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p.path, err)
+	}
+	return creds, nil
+}
+
+// envSecretProvider reads the credentials from environment variables
+type envSecretProvider struct {
+	prefix string
+}
+
+func (p *envSecretProvider) Name() string {
+	return fmt.Sprintf("environment (%sADMIN_KEY, %sINSTANCE_SECRET)", p.prefix, p.prefix)
+}
+
+func (p *envSecretProvider) Load() (*Credentials, error) {
+	creds := &Credentials{
+		AdminKey:       strings.TrimSpace(os.Getenv(p.prefix + "ADMIN_KEY")),
+		InstanceSecret: strings.TrimSpace(os.Getenv(p.prefix + "INSTANCE_SECRET")),
+	}
+	if creds.AdminKey == "" || creds.InstanceSecret == "" {
+		return nil, fmt.Errorf("%sADMIN_KEY and %sINSTANCE_SECRET must both be set", p.prefix, p.prefix)
+	}
+	if err := creds.validate(); err != nil {
+		return nil, err
+	}
+	return creds, nil
+}
+
+// systemdSecretProvider reads a systemd credential containing credentials.json,
+// either passed to this process ($CREDENTIALS_DIRECTORY) or from the system
+// credential store (encrypted with systemd-creds, or plain)
+type systemdSecretProvider struct {
+	name string
+}
+
+func (p *systemdSecretProvider) Name() string {
+	return "systemd credential " + p.name
+}
+
+func (p *systemdSecretProvider) Load() (*Credentials, error) {
+	data, err := p.read()
+	if err != nil {
+		return nil, err
+	}
+	creds, err := parseCredentialsJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("systemd credential %s: %w", p.name, err)
+	}
+	return creds, nil
+}
+
+func (p *systemdSecretProvider) read() ([]byte, error) {
+	if dir := os.Getenv("CREDENTIALS_DIRECTORY"); dir != "" {
+		if data, err := os.ReadFile(filepath.Join(dir, p.name)); err == nil {
+			return data, nil
+		}
+	}
+
+	encrypted := filepath.Join("/etc/credstore.encrypted", p.name)
+	if _, err := os.Stat(encrypted); err == nil {
+		out, err := exec.Command("systemd-creds", "decrypt", "--name="+p.name, encrypted, "-").Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt %s: %w", encrypted, err)
+		}
+		return out, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join("/etc/credstore", p.name))
+	if err != nil {
+		return nil, fmt.Errorf("systemd credential %s not found in $CREDENTIALS_DIRECTORY, /etc/credstore.encrypted or /etc/credstore", p.name)
+	}
+	return data, nil
+}
+
+// keystoreFile is the on-disk format of an encrypted keystore: credentials.json
+// encrypted with AES-256-GCM under a PBKDF2-SHA256 key derived from a passphrase
+type keystoreFile struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       string `json:"salt"`
+	Nonce      string `json:"nonce"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+// keystoreSecretProvider reads an encrypted keystore
+type keystoreSecretProvider struct {
+	path           string
+	passphraseFile string
+}
+
+func (p *keystoreSecretProvider) Name() string {
+	return "keystore " + p.path
+}
+
+func (p *keystoreSecretProvider) Load() (*Credentials, error) {
+	passphrase, err := readPassphraseFile(p.passphraseFile)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return nil, err
+	}
+	return openKeystore(data, passphrase)
+}
+
+// readPassphraseFile reads a keystore passphrase, ignoring a trailing newline
+func readPassphraseFile(path string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("a keystore needs --passphrase-file")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase file: %w", err)
+	}
+	passphrase := strings.TrimRight(string(data), "\r\n")
+	if passphrase == "" {
+		return "", fmt.Errorf("passphrase file %s is empty", path)
+	}
+	return passphrase, nil
+}
+
+// sealKeystore encrypts credentials with a passphrase
+func sealKeystore(creds *Credentials, passphrase string) ([]byte, error) {
+	plaintext, err := json.Marshal(creds)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	aead, err := keystoreCipher(passphrase, salt, keystoreIterations)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	ks := keystoreFile{
+		Version:    1,
+		KDF:        "pbkdf2-sha256",
+		Iterations: keystoreIterations,
+		Salt:       hex.EncodeToString(salt),
+		Nonce:      hex.EncodeToString(nonce),
+		Ciphertext: hex.EncodeToString(aead.Seal(nil, nonce, plaintext, nil)),
+	}
+	data, err := json.MarshalIndent(ks, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// openKeystore decrypts a keystore sealed by sealKeystore
+func openKeystore(data []byte, passphrase string) (*Credentials, error) {
+	var ks keystoreFile
+	if err := json.Unmarshal(data, &ks); err != nil {
+		return nil, fmt.Errorf("keystore is invalid: %w", err)
+	}
+	if ks.Version != 1 || ks.KDF != "pbkdf2-sha256" {
+		return nil, fmt.Errorf("unsupported keystore version %d (%s)", ks.Version, ks.KDF)
+	}
+
+	salt, err1 := hex.DecodeString(ks.Salt)
+	nonce, err2 := hex.DecodeString(ks.Nonce)
+	ciphertext, err3 := hex.DecodeString(ks.Ciphertext)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return nil, fmt.Errorf("keystore is invalid: bad hex encoding")
+	}
+
+	aead, err := keystoreCipher(passphrase, salt, ks.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("keystore is invalid: bad nonce")
+	}
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unlock keystore (wrong passphrase?)")
+	}
+
+	return parseCredentialsJSON(plaintext)
+}
+
+func keystoreCipher(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	if iterations <= 0 {
+		return nil, fmt.Errorf("keystore is invalid: bad iteration count")
+	}
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// vaultSecretProvider reads a Vault KV secret (v1 or v2) with adminKey and
+// instanceSecret fields, authenticating with VAULT_TOKEN
+type vaultSecretProvider struct {
+	url string
+}
+
+func (p *vaultSecretProvider) Name() string {
+	return "vault " + p.url
+}
+
+func (p *vaultSecretProvider) Load() (*Credentials, error) {
+	token := os.Getenv("VAULT_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("VAULT_TOKEN must be set to read %s", p.url)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, p.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if ns := os.Getenv("VAULT_NAMESPACE"); ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+
+	resp, err := (&http.Client{Timeout: 30 * time.Second}).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach vault: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault response: %w", err)
+	}
+
+	var secret struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []string        `json:"errors"`
+	}
+	json.Unmarshal(body, &secret)
+	if resp.StatusCode != http.StatusOK {
+		if len(secret.Errors) > 0 {
+			return nil, fmt.Errorf("vault returned %s: %s", resp.Status, strings.Join(secret.Errors, "; "))
+		}
+		return nil, fmt.Errorf("vault returned %s", resp.Status)
+	}
+
+	// KV v2 nests the secret under data.data
+	data := secret.Data
+	var v2 struct {
+		Data     json.RawMessage `json:"data"`
+		Metadata json.RawMessage `json:"metadata"`
+	}
+	if json.Unmarshal(data, &v2) == nil && len(v2.Data) > 0 && len(v2.Metadata) > 0 {
+		data = v2.Data
+	}
+
+	creds, err := parseCredentialsJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("vault secret: %w", err)
+	}
+	return creds, nil
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	testSecretAdminKey = "convex-self-hosted|01abcdef"
+	testSecretInstance = "4361726c6f73"
+)
+
+func TestParseSecretSource(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"file:/etc/convex/credentials.json", "file /etc/convex/credentials.json"},
+		{"/root/creds.json", "file /root/creds.json"},
+		{"env", "environment (CONVEX_ADMIN_KEY, CONVEX_INSTANCE_SECRET)"},
+		{"env:APP_", "environment (APP_ADMIN_KEY, APP_INSTANCE_SECRET)"},
+		{"systemd", "systemd credential convex"},
+		{"systemd:backend", "systemd credential backend"},
+		{"keystore:/etc/convex/credentials.keystore", "keystore /etc/convex/credentials.keystore"},
+		{"vault:https://vault.internal/v1/secret/data/convex", "vault https://vault.internal/v1/secret/data/convex"},
+	}
+	for _, tt := range tests {
+		provider, err := parseSecretSource(tt.spec, "")
+		if err != nil {
+			t.Errorf("parseSecretSource(%q): %v", tt.spec, err)
+			continue
+		}
+		if provider.Name() != tt.want {
+			t.Errorf("parseSecretSource(%q).Name() = %q, want %q", tt.spec, provider.Name(), tt.want)
+		}
+	}
+
+	for _, spec := range []string{"s3:bucket/creds", "file:", "keystore:", "vault:vault.internal"} {
+		if _, err := parseSecretSource(spec, ""); err == nil {
+			t.Errorf("parseSecretSource(%q): expected error", spec)
+		}
+	}
+}
+
+func TestFileSecretProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	os.WriteFile(path, []byte(`{"adminKey":"convex-self-hosted|01abcdef","instanceSecret":"4361726c6f73\n"}`), 0600)
+
+	creds, err := (&fileSecretProvider{path: path}).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.AdminKey != testSecretAdminKey || creds.InstanceSecret != testSecretInstance {
+		t.Errorf("unexpected credentials: %+v", creds)
+	}
+
+	os.WriteFile(path, []byte(`{"adminKey":"no-instance-name","instanceSecret":"4361"}`), 0600)
+	if _, err := (&fileSecretProvider{path: path}).Load(); err == nil {
+		t.Error("expected an error for an admin key without instance name")
+	}
+}
+
+func TestEnvSecretProvider(t *testing.T) {
+	t.Setenv("CONVEX_ADMIN_KEY", testSecretAdminKey)
+	t.Setenv("CONVEX_INSTANCE_SECRET", "")
+
+	provider := &envSecretProvider{prefix: "CONVEX_"}
+	if _, err := provider.Load(); err == nil {
+		t.Fatal("expected an error with CONVEX_INSTANCE_SECRET unset")
+	}
+
+	t.Setenv("CONVEX_INSTANCE_SECRET", testSecretInstance)
+	creds, err := provider.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.AdminKey != testSecretAdminKey || creds.InstanceSecret != testSecretInstance {
+		t.Errorf("unexpected credentials: %+v", creds)
+	}
+}
+
+func TestSystemdSecretProvider(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("CREDENTIALS_DIRECTORY", dir)
+	os.WriteFile(filepath.Join(dir, "convex"), []byte(`{"adminKey":"convex-self-hosted|01abcdef","instanceSecret":"4361726c6f73"}`), 0600)
+
+	creds, err := (&systemdSecretProvider{name: "convex"}).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.AdminKey != testSecretAdminKey {
+		t.Errorf("unexpected credentials: %+v", creds)
+	}
+}
+
+func TestKeystoreRoundTrip(t *testing.T) {
+	old := keystoreIterations
+	keystoreIterations = 1000
+	defer func() { keystoreIterations = old }()
+
+	dir := t.TempDir()
+	passFile := filepath.Join(dir, "passphrase")
+	os.WriteFile(passFile, []byte("correct horse battery staple\n"), 0600)
+
+	data, err := sealKeystore(&Credentials{AdminKey: testSecretAdminKey, InstanceSecret: testSecretInstance}, "correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), testSecretInstance) || strings.Contains(string(data), "01abcdef") {
+		t.Fatal("keystore contains the plaintext secrets")
+	}
+	keystore := filepath.Join(dir, "credentials.keystore")
+	os.WriteFile(keystore, data, 0600)
+
+	creds, err := (&keystoreSecretProvider{path: keystore, passphraseFile: passFile}).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.AdminKey != testSecretAdminKey || creds.InstanceSecret != testSecretInstance {
+		t.Errorf("unexpected credentials: %+v", creds)
+	}
+
+	if _, err := openKeystore(data, "wrong passphrase"); err == nil {
+		t.Error("expected an error with the wrong passphrase")
+	}
+	if _, err := (&keystoreSecretProvider{path: keystore}).Load(); err == nil {
+		t.Error("expected an error without a passphrase file")
+	}
+}
+
+func TestVaultSecretProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "s.test-token" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/convex":
+			w.Write([]byte(`{"data":{"data":{"adminKey":"convex-self-hosted|01abcdef","instanceSecret":"4361726c6f73"},"metadata":{"version":3}}}`))
+		case "/v1/kv/convex":
+			w.Write([]byte(`{"data":{"adminKey":"convex-self-hosted|01abcdef","instanceSecret":"4361726c6f73"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("VAULT_TOKEN", "s.test-token")
+	for _, path := range []string{"/v1/secret/data/convex", "/v1/kv/convex"} {
+		creds, err := (&vaultSecretProvider{url: server.URL + path}).Load()
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if creds.AdminKey != testSecretAdminKey || creds.InstanceSecret != testSecretInstance {
+			t.Errorf("%s: unexpected credentials: %+v", path, creds)
+		}
+	}
+
+	if _, err := (&vaultSecretProvider{url: server.URL + "/v1/secret/data/missing"}).Load(); err == nil {
+		t.Error("expected an error for a missing secret")
+	}
+
+	t.Setenv("VAULT_TOKEN", "s.wrong")
+	_, err := (&vaultSecretProvider{url: server.URL + "/v1/secret/data/convex"}).Load()
+	if err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("expected permission denied, got %v", err)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
 
 	"github.com/ozanturksever/convex-bundler/pkg/selfhost"
 	"github.com/spf13/cobra"
@@ -96,6 +98,10 @@ func IsSelfHostMode() bool {
 	return isSelfHostMode
 }
 
+// bundlePidFile is written into each extraction directory with the pid of the
+// process using it, so doctor does not remove it from under a long upgrade
+const bundlePidFile = ".convex-backend-ops.pid"
+
 // GetEmbeddedBundlePath extracts the embedded bundle to a temp directory and returns the path
 // The caller is responsible for cleaning up the temp directory
 func GetEmbeddedBundlePath() (string, func(), error) {
@@ -113,6 +119,11 @@ func GetEmbeddedBundlePath() (string, func(), error) {
 		os.RemoveAll(tempDir)
 	}
 
+	if err := os.WriteFile(filepath.Join(tempDir, bundlePidFile), []byte(strconv.Itoa(os.Getpid())+"\n"), 0644); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to write pid file: %w", err)
+	}
+
 	// Extract bundle
 	_, err = selfhost.Extract(selfhost.ExtractOptions{
 		OutputDir: tempDir,
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// semVersion is a parsed semantic version. Build metadata is ignored.
+type semVersion struct {
+	Major, Minor, Patch int
+	Pre                 string
+}
+
+// parseVersion parses MAJOR.MINOR.PATCH with an optional "v" prefix,
+// pre-release suffix and build metadata
+func parseVersion(v string) (semVersion, error) {
+	s := strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.Index(s, "+"); i >= 0 {
+		s = s[:i]
+	}
+
+	var sv semVersion
+	if i := strings.Index(s, "-"); i >= 0 {
+		sv.Pre = s[i+1:]
+		s = s[:i]
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return semVersion{}, fmt.Errorf("invalid version %q", v)
+	}
+
+	nums := make([]int, 3)
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 || strings.HasPrefix(p, "+") {
+			return semVersion{}, fmt.Errorf("invalid version %q", v)
+		}
+		nums[i] = n
+	}
+	sv.Major, sv.Minor, sv.Patch = nums[0], nums[1], nums[2]
+
+	return sv, nil
+}
+
+// isValidVersion reports whether v looks like a semantic version (MAJOR.MINOR.PATCH
+// with optional pre-release and build metadata)
+func isValidVersion(v string) bool {
+	_, err := parseVersion(v)
+	return err == nil
+}
+
+// compare returns -1, 0 or 1 depending on whether a is lower than, equal to
+// or higher than b. A pre-release is lower than the matching release.
+func (a semVersion) compare(b semVersion) int {
+	for _, d := range []int{a.Major - b.Major, a.Minor - b.Minor, a.Patch - b.Patch} {
+		if d < 0 {
+			return -1
+		}
+		if d > 0 {
+			return 1
+		}
+	}
+
+	switch {
+	case a.Pre == b.Pre:
+		return 0
+	case a.Pre == "":
+		return 1
+	case b.Pre == "":
+		return -1
+	}
+	return comparePrerelease(a.Pre, b.Pre)
+}
+
+func (a semVersion) String() string {
+	s := fmt.Sprintf("%d.%d.%d", a.Major, a.Minor, a.Patch)
+	if a.Pre != "" {
+		s += "-" + a.Pre
+	}
+	return s
+}
+
+// comparePrerelease compares dot-separated pre-release identifiers following
+// the semver precedence rules
+func comparePrerelease(a, b string) int {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		na, errA := strconv.Atoi(pa[i])
+		nb, errB := strconv.Atoi(pb[i])
+		switch {
+		case errA == nil && errB == nil:
+			if na != nb {
+				if na < nb {
+					return -1
+				}
+				return 1
+			}
+		case errA == nil:
+			return -1
+		case errB == nil:
+			return 1
+		default:
+			if c := strings.Compare(pa[i], pb[i]); c != 0 {
+				return c
+			}
+		}
+	}
+
+	switch {
+	case len(pa) < len(pb):
+		return -1
+	case len(pa) > len(pb):
+		return 1
+	}
+	return 0
+}
+
+// compareVersions parses and compares two version strings
+func compareVersions(a, b string) (int, error) {
+	va, err := parseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := parseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+	return va.compare(vb), nil
+}
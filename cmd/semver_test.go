@@ -0,0 +1,49 @@
+package cmd
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"1.2.3", "1.2.4", -1},
+		{"1.10.0", "1.9.9", 1},
+		{"2.0.0", "1.99.99", 1},
+		{"1.0.0-rc.1", "1.0.0", -1},
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-alpha.2", "1.0.0-alpha.10", -1},
+		{"1.0.0-beta", "1.0.0-alpha", 1},
+		{"1.0.0+build1", "1.0.0+build2", 0},
+	}
+
+	for _, tt := range tests {
+		got, err := compareVersions(tt.a, tt.b)
+		if err != nil {
+			t.Errorf("compareVersions(%q, %q): %v", tt.a, tt.b, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestIsValidVersion(t *testing.T) {
+	for v, want := range map[string]bool{
+		"1.2.3":        true,
+		"v1.2.3":       true,
+		"1.2.3-rc.1":   true,
+		"1.2.3+build5": true,
+		"1.2":          false,
+		"1.x.3":        false,
+		"dev":          false,
+		"":             false,
+	} {
+		if got := isValidVersion(v); got != want {
+			t.Errorf("isValidVersion(%q) = %v, want %v", v, got, want)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// systemdTimestampLayout is how systemctl show prints timestamps
+const systemdTimestampLayout = "Mon 2006-01-02 15:04:05 MST"
+
+// serviceManager controls one systemd unit. Every start, stop, restart,
+// enable and disable goes through it, so failures carry systemctl's output.
+type serviceManager struct {
+	unit string
+}
+
+var (
+	backendService  = &serviceManager{unit: "convex-backend"}
+	exporterService = &serviceManager{unit: "convex-backend-exporter"}
+)
+
+// systemctl runs systemctl and returns its combined output
+var systemctl = func(args ...string) ([]byte, error) {
+	return exec.Command("systemctl", args...).CombinedOutput()
+}
+
+func (m *serviceManager) run(action string, flags ...string) error {
+	args := append([]string{action}, flags...)
+	output, err := systemctl(append(args, m.unit)...)
+	if err != nil {
+		if msg := strings.TrimSpace(string(output)); msg != "" {
+			return fmt.Errorf("systemctl %s %s: %s", action, m.unit, msg)
+		}
+		return fmt.Errorf("systemctl %s %s: %w", action, m.unit, err)
+	}
+	return nil
+}
+
+func (m *serviceManager) start() error   { return m.run("start") }
+func (m *serviceManager) stop() error    { return m.run("stop") }
+func (m *serviceManager) restart() error { return m.run("restart") }
+
+// enable enables the unit at boot; with now it is also started
+func (m *serviceManager) enable(now bool) error {
+	if now {
+		return m.run("enable", "--now")
+	}
+	return m.run("enable")
+}
+
+// disable disables the unit at boot; with now it is also stopped
+func (m *serviceManager) disable(now bool) error {
+	if now {
+		return m.run("disable", "--now")
+	}
+	return m.run("disable")
+}
+
+// activeState returns the unit's state as printed by systemctl is-active
+func (m *serviceManager) activeState() string {
+	// is-active exits non-zero for anything but active, the state is still printed
+	output, _ := systemctl("is-active", m.unit)
+	if state := strings.TrimSpace(string(output)); state != "" && !strings.Contains(state, " ") {
+		return state
+	}
+	return "unknown"
+}
+
+func (m *serviceManager) isEnabled() bool {
+	output, _ := systemctl("is-enabled", m.unit)
+	return strings.TrimSpace(string(output)) == "enabled"
+}
+
+// daemonReload makes systemd re-read changed unit files
+func daemonReload() error {
+	if output, err := systemctl("daemon-reload"); err != nil {
+		if msg := strings.TrimSpace(string(output)); msg != "" {
+			return fmt.Errorf("systemctl daemon-reload: %s", msg)
+		}
+		return fmt.Errorf("systemctl daemon-reload: %w", err)
+	}
+	return nil
+}
+
+// property returns a single systemd property of the unit, or an empty
+// string if it cannot be read
+func (m *serviceManager) property(name string) string {
+	output, err := systemctl("show", m.unit, "--property", name, "--value")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// properties reads several systemd properties of the unit in one call
+func (m *serviceManager) properties(names ...string) map[string]string {
+	output, err := systemctl("show", m.unit, "--property", strings.Join(names, ","))
+	if err != nil {
+		return map[string]string{}
+	}
+	return parseServiceProperties(string(output))
+}
+
+// parseServiceProperties parses the key=value lines printed by systemctl show
+func parseServiceProperties(output string) map[string]string {
+	props := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		if key, value, ok := strings.Cut(line, "="); ok {
+			props[key] = strings.TrimSpace(value)
+		}
+	}
+	return props
+}
+
+// getServiceRestarts returns how many times systemd has restarted the unit
+// since it was last started
+func getServiceRestarts() int {
+	n, _ := strconv.Atoi(backendService.property("NRestarts"))
+	return n
+}
+
+// ServiceProcess describes the running backend process as seen by systemd
+type ServiceProcess struct {
+	PID            int    `json:"pid"`
+	StartedAt      string `json:"startedAt,omitempty"`
+	UptimeSeconds  int64  `json:"uptimeSeconds"`
+	Restarts       int    `json:"restarts"`
+	MemoryBytes    int64  `json:"memoryBytes"`
+	RSSBytes       int64  `json:"rssBytes"`
+	CPUSeconds     int64  `json:"cpuSeconds"`
+	ListeningPorts []int  `json:"listeningPorts"`
+}
+
+// getServiceProcess returns details of the running backend process, or nil
+// if the service is not running
+func getServiceProcess() *ServiceProcess {
+	props := backendService.properties("MainPID", "ActiveEnterTimestamp", "NRestarts", "MemoryCurrent", "CPUUsageNSec")
+	process := serviceProcessFromProperties(props, time.Now())
+	if process != nil {
+		process.RSSBytes = processRSS(process.PID)
+		process.ListeningPorts = listeningPorts(process.PID)
+	}
+	return process
+}
+
+// processRSS returns the resident set size of a process from /proc, or 0
+func processRSS(pid int) int64 {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/status", pid))
+	if err != nil {
+		return 0
+	}
+	return parseVmRSS(string(data))
+}
+
+// parseVmRSS extracts the VmRSS line ("VmRSS:   1234 kB") of /proc/<pid>/status
+func parseVmRSS(status string) int64 {
+	for _, line := range strings.Split(status, "\n") {
+		if value, ok := strings.CutPrefix(line, "VmRSS:"); ok {
+			fields := strings.Fields(value)
+			if len(fields) == 0 {
+				return 0
+			}
+			kb, _ := strconv.ParseInt(fields[0], 10, 64)
+			return kb * 1024
+		}
+	}
+	return 0
+}
+
+func serviceProcessFromProperties(props map[string]string, now time.Time) *ServiceProcess {
+	pid, _ := strconv.Atoi(props["MainPID"])
+	if pid == 0 {
+		return nil
+	}
+
+	process := &ServiceProcess{PID: pid, ListeningPorts: []int{}}
+	process.Restarts, _ = strconv.Atoi(props["NRestarts"])
+	if started, err := time.ParseInLocation(systemdTimestampLayout, props["ActiveEnterTimestamp"], time.Local); err == nil {
+		process.StartedAt = started.UTC().Format(time.RFC3339)
+		process.UptimeSeconds = int64(now.Sub(started).Seconds())
+	}
+	// Unavailable counters are reported as "[not set]" or the maximum uint64
+	if mem, err := strconv.ParseUint(props["MemoryCurrent"], 10, 64); err == nil && mem < math.MaxInt64 {
+		process.MemoryBytes = int64(mem)
+	}
+	if cpu, err := strconv.ParseUint(props["CPUUsageNSec"], 10, 64); err == nil && cpu < math.MaxInt64 {
+		process.CPUSeconds = int64(cpu / uint64(time.Second))
+	}
+	return process
+}
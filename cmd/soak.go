@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SoakResult records how the backend behaved during a post-upgrade soak period
+type SoakResult struct {
+	Duration     string  `json:"duration"`
+	Passed       bool    `json:"passed"`
+	Restarts     int     `json:"restarts"`
+	Probes       int     `json:"probes"`
+	FailedProbes int     `json:"failedProbes"`
+	ErrorRate    float64 `json:"errorRate"`
+	Reason       string  `json:"reason,omitempty"`
+	CompletedAt  string  `json:"completedAt"`
+}
+
+// soakThresholds are the limits that trigger an automatic rollback
+type soakThresholds struct {
+	maxRestarts  int
+	maxErrorRate float64
+	minProbes    int
+}
+
+// soakUpgrade watches the upgraded backend for the given duration. It fails
+// as soon as systemd restarts the unit more often than allowed or the share
+// of failed health probes exceeds the allowed error rate.
+func soakUpgrade(duration time.Duration, limits soakThresholds) (*SoakResult, error) {
+	settings, err := loadHealthSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	interval := 10 * time.Second
+	if duration/10 < interval {
+		interval = duration / 10
+	}
+	if interval < time.Second {
+		interval = time.Second
+	}
+
+	result := &SoakResult{Duration: duration.String()}
+	baseline := getServiceRestarts()
+
+	deadline := time.Now().Add(duration)
+	for time.Now().Before(deadline) {
+		time.Sleep(interval)
+
+		result.Restarts = getServiceRestarts() - baseline
+		result.Probes++
+		if failed := firstFailedCheck(runHealthChecks(settings)); failed != nil {
+			result.FailedProbes++
+			printInfo("Soak: check %q failed: %s", failed.Name, failed.Error)
+		}
+		result.ErrorRate = float64(result.FailedProbes) / float64(result.Probes)
+
+		if result.Restarts > limits.maxRestarts {
+			result.Reason = fmt.Sprintf("service restarted %d times (limit %d)", result.Restarts, limits.maxRestarts)
+			break
+		}
+		if result.Probes >= limits.minProbes && result.ErrorRate > limits.maxErrorRate {
+			result.Reason = fmt.Sprintf("health check error rate %.0f%% (limit %.0f%%)", result.ErrorRate*100, limits.maxErrorRate*100)
+			break
+		}
+	}
+
+	result.Passed = result.Reason == ""
+	result.CompletedAt = time.Now().UTC().Format(time.RFC3339)
+	return result, nil
+}
+
+// updateBackupMeta rewrites a backup's meta.json after applying fn
+func updateBackupMeta(backupDir string, fn func(*BackupMeta)) error {
+	metaPath := filepath.Join(backupDir, "meta.json")
+	data, err := os.ReadFile(metaPath)
+	if err != nil {
+		return err
+	}
+
+	var meta BackupMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return err
+	}
+
+	fn(&meta)
+
+	data, err = json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(metaPath, data, 0644)
+}
@@ -3,10 +3,13 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
-	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -14,28 +17,94 @@ import (
 
 // StatusOutput represents JSON output for status command
 type StatusOutput struct {
-	Installed      bool      `json:"installed"`
-	Manifest       *Manifest `json:"manifest,omitempty"`
-	ServiceStatus  string    `json:"serviceStatus"`
-	ServiceEnabled bool      `json:"serviceEnabled"`
-	Health         string    `json:"health"`
-	BackendURL     string    `json:"backendUrl"`
+	Installed      bool                `json:"installed"`
+	Manifest       *Manifest           `json:"manifest,omitempty"`
+	BackendVersion string              `json:"backendVersion,omitempty"`
+	ServiceStatus  string              `json:"serviceStatus"`
+	ServiceEnabled bool                `json:"serviceEnabled"`
+	Health         string              `json:"health"`
+	HealthChecks   []HealthCheckResult `json:"healthChecks"`
+	BackendURL     string              `json:"backendUrl"`
+	Process        *ServiceProcess     `json:"process,omitempty"`
+	Storage        *StorageStatus      `json:"storage,omitempty"`
+	LastBackup     *LastBackupStatus   `json:"lastBackup,omitempty"`
+	CollectedAt    string              `json:"collectedAt"`
+}
+
+// StorageStatus describes the database, file storage and data volume
+type StorageStatus struct {
+	DatabaseBytes      int64 `json:"databaseBytes"`
+	StorageObjects     int   `json:"storageObjects"`
+	StorageBytes       int64 `json:"storageBytes"`
+	DiskFreeBytes      int64 `json:"diskFreeBytes"`
+	DiskTotalBytes     int64 `json:"diskTotalBytes"`
+	DiskFreeInodes     int64 `json:"diskFreeInodes"`
+	DiskTotalInodes    int64 `json:"diskTotalInodes"`
+	DiskFreePercentage int   `json:"diskFreePercentage"`
+}
+
+// LastBackupStatus describes the newest backup
+type LastBackupStatus struct {
+	Version    string `json:"version"`
+	Created    string `json:"created"`
+	Reason     string `json:"reason"`
+	AgeSeconds int64  `json:"ageSeconds"`
 }
 
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show current installation status",
-	Long:  `Display the current status of the Convex backend installation.`,
-	RunE:  runStatus,
+	Long: `Display the current status of the Convex backend installation: service
+state and health, the backend process (PID, uptime, restarts, memory, CPU and
+listening ports), database and file storage size, free disk space on the data
+volume, and the age of the last backup.
+
+With --watch the status is refreshed every interval (2s by default, e.g.
+'status --watch 10s') until interrupted; with --json each refresh is printed
+as one JSON object per line. See 'top' for an interactive dashboard.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runStatus,
 }
 
+var statusWatch time.Duration
+
 func init() {
 	rootCmd.AddCommand(statusCmd)
+	statusCmd.Flags().DurationVarP(&statusWatch, "watch", "w", 0, "Refresh every interval until interrupted (2s if no interval is given)")
+	statusCmd.Flags().Lookup("watch").NoOptDefVal = "2s"
 }
 
 func runStatus(cmd *cobra.Command, args []string) error {
+	if len(args) > 0 {
+		// Allow "--watch 10s" as well as "--watch=10s"
+		if !cmd.Flags().Changed("watch") {
+			return fmt.Errorf("unexpected argument %q", args[0])
+		}
+		interval, err := time.ParseDuration(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid --watch interval %q: %w", args[0], err)
+		}
+		statusWatch = interval
+	}
+	if cmd.Flags().Changed("watch") {
+		return watchStatus(statusWatch)
+	}
+
+	output := collectStatus()
+
+	if flagJSON {
+		return printJSON(output)
+	}
+
+	printStatus(output)
+	return nil
+}
+
+// collectStatus gathers everything status reports
+func collectStatus() StatusOutput {
 	output := StatusOutput{
-		BackendURL: "http://localhost:3210",
+		BackendURL:  backendURL(),
+		CollectedAt: time.Now().UTC().Format(time.RFC3339),
 	}
 
 	// Check if installed (manifest exists)
@@ -53,12 +122,62 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	output.ServiceEnabled = isServiceEnabled()
 
 	// Health check
-	output.Health = checkHealth(output.BackendURL)
+	output.Health, output.HealthChecks = checkHealth()
 
-	if flagJSON {
-		return printJSON(output)
+	if !output.Installed {
+		return output
+	}
+
+	if output.ServiceStatus == "active" {
+		output.Process = getServiceProcess()
+		output.BackendVersion = getBackendVersion()
 	}
+	output.Storage = getStorageStatus("/var/lib/convex/data")
+	output.LastBackup = getLastBackup("/var/lib/convex/backups", time.Now())
+
+	return output
+}
+
+// watchStatus prints the status every interval until interrupted
+func watchStatus(interval time.Duration) error {
+	if interval < time.Second {
+		return fmt.Errorf("--watch interval must be at least 1s")
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+
+	clearScreen := !flagJSON && isTerminal(os.Stdout)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		output := collectStatus()
+		if flagJSON {
+			data, err := json.Marshal(output)
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+		} else {
+			if clearScreen {
+				fmt.Print(ansiClearScreen)
+			}
+			printStatus(output)
+			fmt.Println()
+			fmt.Printf("Every %s, updated %s. Press Ctrl-C to stop.\n", interval, time.Now().Format("15:04:05"))
+		}
 
+		select {
+		case <-interrupt:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func printStatus(output StatusOutput) {
 	// Human-readable output
 	fmt.Println("Convex Backend Status")
 	fmt.Println("=====================")
@@ -68,17 +187,64 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		fmt.Println("Status: Not installed")
 		fmt.Println()
 		fmt.Println("Run 'convex-backend-ops install --bundle <path>' to install.")
-		return nil
+		return
 	}
 
 	fmt.Printf("Name:           %s\n", output.Manifest.Name)
 	fmt.Printf("Version:        %s\n", output.Manifest.Version)
+	if output.Manifest.AppsVersion != "" {
+		fmt.Printf("Apps Version:   %s\n", output.Manifest.AppsVersion)
+	}
+	if output.BackendVersion != "" {
+		fmt.Printf("Backend:        %s\n", output.BackendVersion)
+	}
 	fmt.Printf("Service Status: %s", output.ServiceStatus)
 	if output.ServiceEnabled {
 		fmt.Print(" (enabled)")
 	}
 	fmt.Println()
 	fmt.Printf("Health:         %s\n", output.Health)
+	if failed := firstFailedCheck(output.HealthChecks); failed != nil {
+		fmt.Printf("Failing Check:  %s (%s)\n", failed.Name, failed.Error)
+	}
+	fmt.Println()
+
+	if p := output.Process; p != nil {
+		fmt.Println("Process:")
+		fmt.Printf("  PID:      %d\n", p.PID)
+		if p.StartedAt != "" {
+			fmt.Printf("  Uptime:   %s\n", formatAge(time.Duration(p.UptimeSeconds)*time.Second))
+		}
+		fmt.Printf("  Restarts: %d\n", p.Restarts)
+		if p.MemoryBytes > 0 {
+			fmt.Printf("  Memory:   %s (RSS %s)\n", humanizeBytes(p.MemoryBytes), humanizeBytes(p.RSSBytes))
+		}
+		fmt.Printf("  CPU Time: %s\n", time.Duration(p.CPUSeconds)*time.Second)
+		if len(p.ListeningPorts) > 0 {
+			ports := make([]string, len(p.ListeningPorts))
+			for i, port := range p.ListeningPorts {
+				ports[i] = fmt.Sprint(port)
+			}
+			fmt.Printf("  Ports:    %s\n", strings.Join(ports, ", "))
+		}
+		fmt.Println()
+	}
+
+	if s := output.Storage; s != nil {
+		fmt.Println("Storage:")
+		fmt.Printf("  Database:  %s\n", humanizeBytes(s.DatabaseBytes))
+		fmt.Printf("  Files:     %d objects, %s\n", s.StorageObjects, humanizeBytes(s.StorageBytes))
+		if s.DiskTotalBytes > 0 {
+			fmt.Printf("  Disk Free: %s of %s (%d%%)\n", humanizeBytes(s.DiskFreeBytes), humanizeBytes(s.DiskTotalBytes), s.DiskFreePercentage)
+		}
+		fmt.Println()
+	}
+
+	if b := output.LastBackup; b != nil {
+		fmt.Printf("Last Backup:    v%s, %s ago (%s)\n", b.Version, formatAge(time.Duration(b.AgeSeconds)*time.Second), b.Reason)
+	} else {
+		fmt.Println("Last Backup:    none")
+	}
 	fmt.Println()
 
 	if len(output.Manifest.Apps) > 0 {
@@ -93,42 +259,104 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	fmt.Println("  Binary: /usr/local/bin/convex-backend")
 	fmt.Println("  Data:   /var/lib/convex/data/")
 	fmt.Println("  Config: /etc/convex/")
-
-	return nil
 }
 
 func getServiceStatus() string {
-	cmd := exec.Command("systemctl", "is-active", "convex-backend")
-	output, _ := cmd.Output()
-	status := string(output)
-	if len(status) > 0 && status[len(status)-1] == '\n' {
-		status = status[:len(status)-1]
-	}
-	if status == "" {
-		return "unknown"
-	}
-	return status
+	return backendService.activeState()
 }
 
 func isServiceEnabled() bool {
-	cmd := exec.Command("systemctl", "is-enabled", "convex-backend")
-	output, _ := cmd.Output()
-	return string(output) == "enabled\n"
+	return backendService.isEnabled()
 }
 
-func checkHealth(backendURL string) string {
-	client := &http.Client{
-		Timeout: 5 * time.Second,
+// getBackendVersion returns the version reported by the backend's /version
+// endpoint, or an empty string if it cannot be reached
+func getBackendVersion() string {
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get(backendURL() + "/version")
+	if err != nil {
+		return ""
 	}
+	defer resp.Body.Close()
 
-	resp, err := client.Get(backendURL + "/version")
+	if resp.StatusCode != http.StatusOK {
+		return ""
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 256))
 	if err != nil {
-		return "unhealthy"
+		return ""
 	}
-	defer resp.Body.Close()
+	return strings.TrimSpace(string(body))
+}
+
+// getStorageStatus measures the database, file storage and the volume they live on
+func getStorageStatus(dataDir string) *StorageStatus {
+	status := &StorageStatus{}
+
+	// The write-ahead log holds changes not yet checkpointed into the database
+	for _, name := range []string{"convex.db", "convex.db-wal"} {
+		if info, err := os.Stat(filepath.Join(dataDir, name)); err == nil {
+			status.DatabaseBytes += info.Size()
+		}
+	}
+
+	filepath.Walk(filepath.Join(dataDir, "storage"), func(_ string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			status.StorageObjects++
+			status.StorageBytes += info.Size()
+		}
+		return nil
+	})
+
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(dataDir, &fs); err == nil {
+		status.DiskFreeBytes = int64(fs.Bavail) * int64(fs.Bsize)
+		status.DiskTotalBytes = int64(fs.Blocks) * int64(fs.Bsize)
+		status.DiskFreeInodes = int64(fs.Ffree)
+		status.DiskTotalInodes = int64(fs.Files)
+		if status.DiskTotalBytes > 0 {
+			status.DiskFreePercentage = int(status.DiskFreeBytes * 100 / status.DiskTotalBytes)
+		}
+	}
+
+	return status
+}
+
+// getLastBackup returns the newest backup in backupsDir, or nil if there is none
+func getLastBackup(backupsDir string, now time.Time) *LastBackupStatus {
+	backups, err := listBackups(backupsDir)
+	if err != nil || len(backups) == 0 {
+		return nil
+	}
+
+	newest := backups[0]
+	last := &LastBackupStatus{
+		Version: newest.Version,
+		Created: newest.Created,
+		Reason:  newest.Reason,
+	}
+	if created, err := time.Parse(time.RFC3339, newest.Created); err == nil {
+		last.AgeSeconds = int64(now.Sub(created).Seconds())
+	}
+	return last
+}
+
+// formatAge formats a duration in its two largest units, e.g. "3d 4h" or "12m 5s"
+func formatAge(d time.Duration) string {
+	d = d.Round(time.Second)
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	minutes := int(d.Minutes()) % 60
+	seconds := int(d.Seconds()) % 60
 
-	if resp.StatusCode == http.StatusOK {
-		return "healthy"
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	case minutes > 0:
+		return fmt.Sprintf("%dm %ds", minutes, seconds)
+	default:
+		return fmt.Sprintf("%ds", seconds)
 	}
-	return "unhealthy"
 }
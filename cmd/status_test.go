@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestServiceProcessFromProperties(t *testing.T) {
+	started := time.Date(2025, 1, 15, 10, 0, 0, 0, time.Local)
+	props := parseServiceProperties("MainPID=4242\nActiveEnterTimestamp=" + started.Format(systemdTimestampLayout) +
+		"\nNRestarts=2\nMemoryCurrent=536870912\nCPUUsageNSec=90500000000\n")
+
+	process := serviceProcessFromProperties(props, started.Add(3*time.Hour))
+	if process == nil {
+		t.Fatal("expected a process")
+	}
+	if process.PID != 4242 || process.Restarts != 2 || process.MemoryBytes != 512<<20 || process.CPUSeconds != 90 {
+		t.Errorf("unexpected process: %+v", process)
+	}
+	if process.UptimeSeconds != 3*3600 {
+		t.Errorf("uptime = %d, want %d", process.UptimeSeconds, 3*3600)
+	}
+
+	props["MemoryCurrent"] = "[not set]"
+	props["CPUUsageNSec"] = "18446744073709551615"
+	process = serviceProcessFromProperties(props, started)
+	if process.MemoryBytes != 0 || process.CPUSeconds != 0 {
+		t.Errorf("expected unset counters to be zero: %+v", process)
+	}
+
+	if serviceProcessFromProperties(map[string]string{"MainPID": "0"}, started) != nil {
+		t.Error("expected no process for a stopped service")
+	}
+}
+
+func TestParseProcNetListeners(t *testing.T) {
+	table := `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:0CA2 00000000:0000 0A 00000000:00000000 00:00000000 00000000   996        0 31337 1 0000000000000000 100 0 0 10 0
+   1: 0100007F:0CA3 00000000:0000 0A 00000000:00000000 00:00000000 00000000   996        0 31338 1 0000000000000000 100 0 0 10 0
+   2: 0100007F:0CA2 0100007F:A1B2 01 00000000:00000000 00:00000000 00000000   996        0 31339 1 0000000000000000 20 4 30 10 -1
+`
+	want := map[string]int{"31337": 3234, "31338": 3235}
+	if got := parseProcNetListeners(table); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseProcNetListeners = %v, want %v", got, want)
+	}
+}
+
+func TestGetStorageStatus(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "convex.db"), make([]byte, 4096), 0644)
+	os.WriteFile(filepath.Join(dir, "convex.db-wal"), make([]byte, 1024), 0644)
+	os.MkdirAll(filepath.Join(dir, "storage", "ab"), 0755)
+	os.WriteFile(filepath.Join(dir, "storage", "ab", "one"), make([]byte, 100), 0644)
+	os.WriteFile(filepath.Join(dir, "storage", "two"), make([]byte, 50), 0644)
+
+	status := getStorageStatus(dir)
+	if status.DatabaseBytes != 5120 || status.StorageObjects != 2 || status.StorageBytes != 150 {
+		t.Errorf("unexpected storage status: %+v", status)
+	}
+	if status.DiskTotalBytes == 0 || status.DiskFreeBytes > status.DiskTotalBytes {
+		t.Errorf("unexpected disk figures: %+v", status)
+	}
+}
+
+func TestGetLastBackup(t *testing.T) {
+	dir := t.TempDir()
+	if getLastBackup(dir, time.Now()) != nil {
+		t.Fatal("expected no backup in an empty directory")
+	}
+
+	for version, ts := range map[string]string{"1.0.0": "2025-01-10T00:00:00Z", "1.1.0": "2025-01-14T00:00:00Z"} {
+		os.MkdirAll(filepath.Join(dir, "v"+version), 0755)
+		os.WriteFile(filepath.Join(dir, "v"+version, "meta.json"), []byte(`{"version":"`+version+`","timestamp":"`+ts+`","reason":"upgrade"}`), 0644)
+	}
+
+	now := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+	last := getLastBackup(dir, now)
+	if last == nil || last.Version != "1.1.0" || last.AgeSeconds != 86400 {
+		t.Errorf("unexpected last backup: %+v", last)
+	}
+}
+
+func TestFormatAge(t *testing.T) {
+	tests := map[time.Duration]string{
+		42 * time.Second:              "42s",
+		5*time.Minute + 3*time.Second: "5m 3s",
+		2*time.Hour + 30*time.Minute:  "2h 30m",
+		50*time.Hour + 10*time.Minute: "2d 2h",
+	}
+	for d, want := range tests {
+		if got := formatAge(d); got != want {
+			t.Errorf("formatAge(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
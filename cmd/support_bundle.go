@@ -0,0 +1,364 @@
+package cmd
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// supportIndexName is the archive entry listing what was collected
+const supportIndexName = "index.json"
+
+// SupportBundleFile describes one collected entry of a support bundle
+type SupportBundleFile struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+	Size   int    `json:"size"`
+	Error  string `json:"error,omitempty"`
+}
+
+// SupportBundleIndex is written to the archive as index.json
+type SupportBundleIndex struct {
+	CreatedAt  string              `json:"createdAt"`
+	Hostname   string              `json:"hostname"`
+	OpsVersion string              `json:"opsVersion"`
+	LogsSince  string              `json:"logsSince"`
+	Redacted   bool                `json:"redacted"`
+	Files      []SupportBundleFile `json:"files"`
+}
+
+// SupportBundleOutput represents JSON output for the support-bundle command
+type SupportBundleOutput struct {
+	Path      string `json:"path"`
+	Size      int64  `json:"size"`
+	SizeHuman string `json:"sizeHuman"`
+	Files     int    `json:"files"`
+	Failed    int    `json:"failed"`
+	Redacted  bool   `json:"redacted"`
+}
+
+// SystemInfo describes the host in a support bundle
+type SystemInfo struct {
+	Hostname       string `json:"hostname"`
+	OS             string `json:"os"`
+	Kernel         string `json:"kernel"`
+	Arch           string `json:"arch"`
+	CPUs           int    `json:"cpus"`
+	MemTotal       string `json:"memTotal"`
+	Uptime         string `json:"uptime"`
+	OpsVersion     string `json:"opsVersion"`
+	SystemdVersion string `json:"systemdVersion"`
+	Timezone       string `json:"timezone"`
+	CollectedAt    string `json:"collectedAt"`
+}
+
+// supportItem is one file to collect into a support bundle
+type supportItem struct {
+	name    string
+	source  string
+	collect func() ([]byte, error)
+}
+
+// secretEnvKeyPattern matches dotenv keys whose values are redacted
+var secretEnvKeyPattern = regexp.MustCompile(`(?i)(SECRET|KEY|TOKEN|PASSWORD|PASSWD|CREDENTIAL|AUTH)`)
+
+var (
+	supportBundleOutput string
+	supportBundleSince  string
+)
+
+var supportBundleCmd = &cobra.Command{
+	Use:   "support-bundle",
+	Short: "Collect diagnostics into an archive for support",
+	Long: `Collect configuration, manifest, backup metadata, status, doctor findings,
+recent service logs and system information into a single .tar.gz archive to
+attach to a vendor escalation.
+
+The admin key and instance secret are scrubbed from every collected file, and
+values of secret-looking variables in convex.env are redacted. index.json in
+the archive lists each entry, where it came from and anything that could not
+be collected.`,
+	Args: cobra.NoArgs,
+	RunE: runSupportBundle,
+}
+
+func init() {
+	rootCmd.AddCommand(supportBundleCmd)
+	supportBundleCmd.Flags().StringVarP(&supportBundleOutput, "output", "o", "", "Archive path (default convex-support-<host>-<timestamp>.tar.gz)")
+	supportBundleCmd.Flags().StringVar(&supportBundleSince, "since", "24 hours ago", "Include service logs since this time")
+}
+
+func runSupportBundle(cmd *cobra.Command, args []string) error {
+	// Reading the credentials is required to scrub them
+	if err := checkRoot(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	hostname, _ := os.Hostname()
+	path := supportBundleOutput
+	if path == "" {
+		path = fmt.Sprintf("convex-support-%s-%s.tar.gz", hostname, now.Format("20060102-150405"))
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer f.Close()
+
+	printInfo("Collecting support bundle...")
+	redactor := newSecretRedactor()
+	if redactor.err != nil {
+		printError("Warning: cannot read the installed credentials (%v); only values shaped like secrets will be redacted", redactor.err)
+	}
+	index, err := writeSupportBundle(f, supportItems(supportBundleSince), redactor, now)
+	if err != nil {
+		os.Remove(path)
+		return fmt.Errorf("failed to write support bundle: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return fmt.Errorf("failed to write support bundle: %w", err)
+	}
+
+	output := SupportBundleOutput{Path: path, Files: len(index.Files), Redacted: index.Redacted}
+	if stat, err := os.Stat(path); err == nil {
+		output.Size = stat.Size()
+		output.SizeHuman = humanizeBytes(stat.Size())
+	}
+	for _, file := range index.Files {
+		if file.Error != "" {
+			output.Failed++
+		}
+	}
+
+	if flagJSON {
+		return printJSON(output)
+	}
+
+	if !flagQuiet {
+		for _, file := range index.Files {
+			if file.Error != "" {
+				fmt.Printf("  ! %-30s %s\n", file.Name, file.Error)
+			} else {
+				fmt.Printf("  + %-30s %s\n", file.Name, humanizeBytes(int64(file.Size)))
+			}
+		}
+	}
+	printSuccess("Wrote support bundle to %s (%s, %d files)", path, output.SizeHuman, output.Files)
+	if output.Failed > 0 {
+		printInfo("%d item(s) could not be collected; see index.json in the archive", output.Failed)
+	}
+	if output.Redacted {
+		printInfo("Secrets have been redacted, but review the archive before sharing it.")
+	} else {
+		printInfo("The installed credentials could not be read, so only values shaped like secrets were redacted. Review the archive before sharing it.")
+	}
+	return nil
+}
+
+// supportItems lists what a support bundle collects
+func supportItems(logsSince string) []supportItem {
+	return []supportItem{
+		{name: "config/convex.env", source: "/etc/convex/convex.env", collect: func() ([]byte, error) {
+			data, err := os.ReadFile("/etc/convex/convex.env")
+			if err != nil {
+				return nil, err
+			}
+			return []byte(redactEnvFile(string(data))), nil
+		}},
+		{name: "config/convex-backend.service", source: "/etc/systemd/system/convex-backend.service", collect: readFileItem("/etc/systemd/system/convex-backend.service")},
+		{name: "config/convex-backend-exporter.service", source: exporterUnitPath, collect: readOptionalFileItem(exporterUnitPath)},
+		{name: "config/health.json", source: healthConfigPath, collect: readOptionalFileItem(healthConfigPath)},
+		{name: "manifest.json", source: "/var/lib/convex/manifest.json", collect: readFileItem("/var/lib/convex/manifest.json")},
+		{name: "backups.json", source: "list-backups --json", collect: func() ([]byte, error) {
+			backups, err := listBackups("/var/lib/convex/backups")
+			if err != nil {
+				return nil, err
+			}
+			return json.MarshalIndent(backups, "", "  ")
+		}},
+		{name: "status.json", source: "status --json", collect: func() ([]byte, error) {
+			return json.MarshalIndent(collectStatus(), "", "  ")
+		}},
+		{name: "doctor.json", source: "doctor --json", collect: func() ([]byte, error) {
+			return json.MarshalIndent(runDoctorChecks(false), "", "  ")
+		}},
+		{name: "logs/convex-backend.log", source: "journalctl -u convex-backend --since " + logsSince,
+			collect: commandItem("journalctl", "-u", "convex-backend", "--since", logsSince, "-o", "short-iso", "--no-pager")},
+		{name: "logs/systemctl-status.txt", source: "systemctl status convex-backend",
+			collect: commandItem("systemctl", "status", "convex-backend", "--no-pager", "--full")},
+		{name: "system/info.json", source: "/proc, /etc/os-release", collect: func() ([]byte, error) {
+			return json.MarshalIndent(collectSystemInfo(), "", "  ")
+		}},
+		{name: "system/df.txt", source: "df -h", collect: commandItem("df", "-h")},
+		{name: "system/df-inodes.txt", source: "df -i", collect: commandItem("df", "-i")},
+		{name: "system/timedatectl.txt", source: "timedatectl", collect: commandItem("timedatectl")},
+	}
+}
+
+// writeSupportBundle collects each item, redacts it and writes a gzipped tar
+// archive with an index of what was collected. The index is marked redacted
+// only if the installed credentials were available to the redactor.
+func writeSupportBundle(w io.Writer, items []supportItem, redactor *secretRedactor, now time.Time) (SupportBundleIndex, error) {
+	redact := redactor.Replace
+	hostname, _ := os.Hostname()
+	index := SupportBundleIndex{
+		CreatedAt:  now.UTC().Format(time.RFC3339),
+		Hostname:   hostname,
+		OpsVersion: Version,
+		LogsSince:  supportBundleSince,
+		Redacted:   redactor.err == nil,
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	prefix := "convex-support-" + now.Format("20060102-150405") + "/"
+
+	for _, item := range items {
+		file := SupportBundleFile{Name: item.name, Source: item.source}
+		data, err := item.collect()
+		if err != nil {
+			file.Error = redact(err.Error())
+			index.Files = append(index.Files, file)
+			continue
+		}
+		if data == nil {
+			// Optional files that do not exist on this host
+			continue
+		}
+
+		data = []byte(redact(string(data)))
+		file.Size = len(data)
+		if err := writeTarFile(tw, prefix+item.name, data, now); err != nil {
+			return index, err
+		}
+		index.Files = append(index.Files, file)
+	}
+
+	indexData, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return index, err
+	}
+	if err := writeTarFile(tw, prefix+supportIndexName, indexData, now); err != nil {
+		return index, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return index, err
+	}
+	return index, gz.Close()
+}
+
+func writeTarFile(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: modTime,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+func readFileItem(path string) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		return os.ReadFile(path)
+	}
+}
+
+// readOptionalFileItem is like readFileItem but skips files that do not exist
+func readOptionalFileItem(path string) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return data, err
+	}
+}
+
+// commandItem captures a command's output; a non-zero exit is kept in the
+// output since e.g. systemctl status exits 3 for a stopped service
+func commandItem(name string, args ...string) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		output, err := exec.Command(name, args...).CombinedOutput()
+		if _, ok := err.(*exec.ExitError); ok {
+			return append(output, fmt.Sprintf("\n[%s exited: %v]\n", name, err)...), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return output, nil
+	}
+}
+
+// redactEnvFile redacts the values of secret-looking variables in a dotenv file
+func redactEnvFile(content string) string {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || strings.HasPrefix(strings.TrimSpace(key), "#") {
+			continue
+		}
+		if secretEnvKeyPattern.MatchString(key) && strings.TrimSpace(value) != "" {
+			lines[i] = key + "=" + redactSecret(strings.Trim(value, `"'`))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func collectSystemInfo() SystemInfo {
+	hostname, _ := os.Hostname()
+	info := SystemInfo{
+		Hostname:    hostname,
+		OS:          runtime.GOOS,
+		Arch:        runtime.GOARCH,
+		CPUs:        runtime.NumCPU(),
+		OpsVersion:  Version,
+		Timezone:    time.Now().Format("MST -0700"),
+		CollectedAt: time.Now().Format(time.RFC3339),
+	}
+
+	if data, err := os.ReadFile("/etc/os-release"); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if value, ok := strings.CutPrefix(line, "PRETTY_NAME="); ok {
+				info.OS = strings.Trim(value, `"`)
+			}
+		}
+	}
+	if data, err := os.ReadFile("/proc/sys/kernel/osrelease"); err == nil {
+		info.Kernel = strings.TrimSpace(string(data))
+	}
+	if data, err := os.ReadFile("/proc/meminfo"); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if value, ok := strings.CutPrefix(line, "MemTotal:"); ok {
+				info.MemTotal = strings.TrimSpace(value)
+			}
+		}
+	}
+	if data, err := os.ReadFile("/proc/uptime"); err == nil {
+		var seconds float64
+		if _, err := fmt.Sscanf(string(data), "%f", &seconds); err == nil {
+			info.Uptime = formatAge(time.Duration(seconds) * time.Second)
+		}
+	}
+	if output, err := exec.Command("systemctl", "--version").Output(); err == nil {
+		info.SystemdVersion, _, _ = strings.Cut(string(output), "\n")
+	}
+	return info
+}
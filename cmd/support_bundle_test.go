@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteSupportBundle(t *testing.T) {
+	items := []supportItem{
+		{name: "config/unit", source: "unit", collect: func() ([]byte, error) {
+			return []byte("ExecStart=backend --instance-secret 0123456789abcdef\n"), nil
+		}},
+		{name: "config/optional", source: "missing", collect: func() ([]byte, error) { return nil, nil }},
+		{name: "status.json", source: "status", collect: func() ([]byte, error) {
+			return nil, errors.New("backend unreachable")
+		}},
+	}
+	redactor := &secretRedactor{known: strings.NewReplacer("0123456789abcdef", "****cdef")}
+
+	var buf bytes.Buffer
+	index, err := writeSupportBundle(&buf, items, redactor, time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]string)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(tr)
+		files[header.Name] = string(data)
+	}
+
+	prefix := "convex-support-20240115-100000/"
+	unit, ok := files[prefix+"config/unit"]
+	if !ok {
+		t.Fatalf("expected the unit in the archive, got %v", files)
+	}
+	if strings.Contains(unit, "0123456789abcdef") || !strings.Contains(unit, "****cdef") {
+		t.Errorf("expected the secret to be redacted, got %q", unit)
+	}
+	if _, ok := files[prefix+"config/optional"]; ok {
+		t.Error("expected a missing optional file to be skipped")
+	}
+
+	var archived SupportBundleIndex
+	if err := json.Unmarshal([]byte(files[prefix+supportIndexName]), &archived); err != nil {
+		t.Fatalf("failed to parse index: %v", err)
+	}
+	if len(archived.Files) != 2 || len(index.Files) != 2 {
+		t.Fatalf("expected 2 indexed files, got %+v", archived.Files)
+	}
+	if archived.Files[1].Name != "status.json" || archived.Files[1].Error != "backend unreachable" {
+		t.Errorf("expected the failed item to be indexed with its error, got %+v", archived.Files[1])
+	}
+	if !archived.Redacted {
+		t.Error("expected the index to be marked redacted")
+	}
+}
+
+func TestWriteSupportBundle_UnreadableCredentials(t *testing.T) {
+	items := []supportItem{
+		{name: "config/unit", source: "unit", collect: func() ([]byte, error) {
+			return []byte("ExecStart=backend --instance-secret 0123456789abcdef --port 3210\n"), nil
+		}},
+	}
+	redactor := &secretRedactor{known: strings.NewReplacer(), err: errors.New("permission denied")}
+
+	var buf bytes.Buffer
+	index, err := writeSupportBundle(&buf, items, redactor, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index.Redacted {
+		t.Error("expected the index not to claim redaction without the credentials")
+	}
+
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(gz)
+	if strings.Contains(string(data), "0123456789abcdef") {
+		t.Error("expected the --instance-secret argument to be scrubbed by pattern")
+	}
+}
+
+func TestRedactEnvFile(t *testing.T) {
+	content := "# comment with KEY\nRUST_LOG=info\nOPENAI_API_KEY=sk-0123456789abcdef\nDB_PASSWORD=\"hunter2hunter2\"\nEMPTY_TOKEN=\n"
+	got := redactEnvFile(content)
+
+	for _, want := range []string{"# comment with KEY\n", "RUST_LOG=info\n", "OPENAI_API_KEY=****cdef\n", "DB_PASSWORD=****ter2\n", "EMPTY_TOKEN=\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "sk-0123") || strings.Contains(got, "hunter2h") {
+		t.Errorf("secret values leaked:\n%s", got)
+	}
+}
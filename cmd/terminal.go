@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"unicode/utf8"
+)
+
+// ANSI escape sequences used by the full-screen views
+const (
+	ansiAltScreenOn  = "\x1b[?1049h"
+	ansiAltScreenOff = "\x1b[?1049l"
+	ansiHideCursor   = "\x1b[?25l"
+	ansiShowCursor   = "\x1b[?25h"
+	ansiClearScreen  = "\x1b[H\x1b[2J"
+	ansiReverse      = "\x1b[7m"
+	ansiReset        = "\x1b[0m"
+)
+
+// isTerminal reports whether f is connected to a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// stty runs stty against the terminal on stdin
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	output, err := cmd.Output()
+	return strings.TrimSpace(string(output)), err
+}
+
+// enterRawMode switches the terminal to unbuffered, no-echo input and
+// returns a function that restores the previous settings
+func enterRawMode() (func(), error) {
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read terminal settings: %w", err)
+	}
+	if _, err := stty("-icanon", "-echo", "min", "1"); err != nil {
+		return nil, fmt.Errorf("failed to configure terminal: %w", err)
+	}
+	return func() { stty(saved) }, nil
+}
+
+// terminalSize returns the terminal's rows and columns, defaulting to 24x80
+func terminalSize() (int, int) {
+	var rows, cols int
+	if size, err := stty("size"); err == nil {
+		fmt.Sscanf(size, "%d %d", &rows, &cols)
+	}
+	if rows <= 0 || cols <= 0 {
+		return 24, 80
+	}
+	return rows, cols
+}
+
+// truncateLine shortens a line to width runes
+func truncateLine(line string, width int) string {
+	if utf8.RuneCountInString(line) <= width {
+		return line
+	}
+	runes := []rune(line)
+	return string(runes[:width])
+}
@@ -0,0 +1,341 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// dashboardLogLines is how many journal lines the dashboard fetches
+const dashboardLogLines = 50
+
+// dashboardSnapshot is the data shown by one refresh of the dashboard
+type dashboardSnapshot struct {
+	Status  StatusOutput
+	Backups []BackupInfo
+	Logs    []string
+}
+
+// dashboard is the state of the interactive top view
+type dashboard struct {
+	interval time.Duration
+	snapshot *dashboardSnapshot
+	message  string
+	// pending is an action waiting for the user to confirm with 'y'
+	pending string
+}
+
+var topInterval time.Duration
+
+var topCmd = &cobra.Command{
+	Use:   "top",
+	Short: "Live full-screen dashboard",
+	Long: `Show a full-screen dashboard of service state, health, resource usage,
+storage, backups and recent service logs, refreshed every --interval.
+
+Keys:
+  r  restart the backend (asks for confirmation, needs root)
+  b  back up the installed version (asks for confirmation, needs root)
+  l  follow the service logs; Ctrl-C returns to the dashboard
+  q  quit
+
+For non-interactive use, see 'status --watch'.`,
+	Args: cobra.NoArgs,
+	RunE: runTop,
+}
+
+func init() {
+	rootCmd.AddCommand(topCmd)
+	topCmd.Flags().DurationVarP(&topInterval, "interval", "n", 2*time.Second, "Refresh interval")
+}
+
+func runTop(cmd *cobra.Command, args []string) error {
+	if !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+		return fmt.Errorf("top needs an interactive terminal, use 'status --watch' instead")
+	}
+	if topInterval < time.Second {
+		return fmt.Errorf("--interval must be at least 1s")
+	}
+
+	restore, err := enterRawMode()
+	if err != nil {
+		return err
+	}
+	fmt.Print(ansiAltScreenOn + ansiHideCursor)
+	defer func() {
+		fmt.Print(ansiShowCursor + ansiAltScreenOff)
+		restore()
+	}()
+
+	d := &dashboard{interval: topInterval}
+	return d.run()
+}
+
+func (d *dashboard) run() error {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+
+	keys := make(chan byte)
+	go readKeys(keys)
+
+	snapshots := make(chan dashboardSnapshot, 1)
+	collecting := true
+	go func() { snapshots <- collectDashboard() }()
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		d.render()
+
+		select {
+		case s := <-snapshots:
+			d.snapshot = &s
+			collecting = false
+		case <-ticker.C:
+			if !collecting {
+				collecting = true
+				go func() { snapshots <- collectDashboard() }()
+			}
+		case key := <-keys:
+			if d.handleKey(key) {
+				return nil
+			}
+			if key == 'l' {
+				// The log viewer was stopped with Ctrl-C, which we received too
+				drainSignals(sigs)
+			}
+		case <-sigs:
+			return nil
+		}
+	}
+}
+
+// handleKey reacts to a key press and reports whether to quit
+func (d *dashboard) handleKey(key byte) bool {
+	if d.pending != "" {
+		action := d.pending
+		d.pending = ""
+		if key != 'y' && key != 'Y' {
+			d.message = action + " cancelled"
+			return false
+		}
+		d.runAction(action)
+		return false
+	}
+
+	switch key {
+	case 'q', 'Q':
+		return true
+	case 'r', 'b':
+		if os.Geteuid() != 0 {
+			d.message = "restart and backup need root (use sudo)"
+			return false
+		}
+		d.pending = map[byte]string{'r': "restart", 'b': "backup"}[key]
+		d.message = fmt.Sprintf("Confirm %s? [y/N]", d.pending)
+		if d.pending == "backup" {
+			d.message = "Confirm backup? The backend is stopped during the copy [y/N]"
+		}
+	case 'l':
+		d.tailLogs()
+	}
+	return false
+}
+
+func (d *dashboard) runAction(action string) {
+	switch action {
+	case "restart":
+		d.message = "Restarting..."
+		d.render()
+		output := performServiceAction("restart", backendService.restart, false)
+		auditErr := auditServiceAction(output)
+		switch {
+		case !output.Success:
+			d.message = "restart failed: " + output.Error
+		case auditErr != nil:
+			d.message = fmt.Sprintf("Restarted at %s, but the audit log could not be written: %v", time.Now().Format("15:04:05"), auditErr)
+		default:
+			d.message = "Restarted at " + time.Now().Format("15:04:05")
+		}
+	case "backup":
+		d.message = "Creating backup..."
+		d.render()
+		if backupDir, err := createManualBackup(); err != nil {
+			d.message = fmt.Sprintf("backup failed: %v", err)
+		} else {
+			d.message = "Backup created: " + backupDir
+		}
+	}
+}
+
+// tailLogs leaves the dashboard to follow the journal until Ctrl-C
+func (d *dashboard) tailLogs() {
+	fmt.Print(ansiShowCursor + ansiAltScreenOff)
+	fmt.Println("Following convex-backend logs, press Ctrl-C to return to the dashboard...")
+
+	cmd := exec.Command("journalctl", "-u", "convex-backend", "-f", "-n", "20", "--no-pager")
+	cmd.Stderr = os.Stderr
+	if stdout, err := cmd.StdoutPipe(); err == nil && cmd.Start() == nil {
+		redactor := newSecretRedactor()
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+		for scanner.Scan() {
+			fmt.Println(redactor.Replace(scanner.Text()))
+		}
+		cmd.Wait()
+	}
+
+	fmt.Print(ansiAltScreenOn + ansiHideCursor)
+	d.message = ""
+}
+
+func (d *dashboard) render() {
+	rows, cols := terminalSize()
+	lines := renderDashboard(d.snapshot, d.message, d.interval, time.Now(), rows, cols)
+	fmt.Print(ansiClearScreen + strings.Join(lines, "\n"))
+}
+
+// renderDashboard lays out a snapshot as exactly rows lines of at most cols
+// characters (plus highlighting)
+func renderDashboard(snap *dashboardSnapshot, message string, interval time.Duration, now time.Time, rows, cols int) []string {
+	title := "convex-backend-ops top"
+	var body []string
+
+	if snap == nil {
+		body = append(body, "", "Collecting status...")
+	} else {
+		s := snap.Status
+		if s.Manifest != nil {
+			title += fmt.Sprintf("  %s v%s", s.Manifest.Name, s.Manifest.Version)
+		}
+		body = append(body, "")
+
+		service := "Service:  " + s.ServiceStatus
+		if s.ServiceEnabled {
+			service += " (enabled)"
+		}
+		service += "    Health: " + s.Health
+		if s.BackendVersion != "" {
+			service += "    Backend: " + s.BackendVersion
+		}
+		body = append(body, service)
+		if failed := firstFailedCheck(s.HealthChecks); failed != nil {
+			body = append(body, fmt.Sprintf("Failing:  %s (%s)", failed.Name, failed.Error))
+		}
+
+		if p := s.Process; p != nil {
+			ports := make([]string, len(p.ListeningPorts))
+			for i, port := range p.ListeningPorts {
+				ports[i] = fmt.Sprint(port)
+			}
+			body = append(body, fmt.Sprintf("Process:  PID %d  up %s  restarts %d  mem %s  cpu %s  ports %s",
+				p.PID, formatAge(time.Duration(p.UptimeSeconds)*time.Second), p.Restarts,
+				humanizeBytes(p.MemoryBytes), time.Duration(p.CPUSeconds)*time.Second, strings.Join(ports, ",")))
+		} else {
+			body = append(body, "Process:  not running")
+		}
+
+		if st := s.Storage; st != nil {
+			body = append(body, fmt.Sprintf("Storage:  db %s  files %d (%s)  disk free %s of %s (%d%%)",
+				humanizeBytes(st.DatabaseBytes), st.StorageObjects, humanizeBytes(st.StorageBytes),
+				humanizeBytes(st.DiskFreeBytes), humanizeBytes(st.DiskTotalBytes), st.DiskFreePercentage))
+		}
+
+		body = append(body, "", fmt.Sprintf("Backups (%d)", len(snap.Backups)))
+		for i, b := range snap.Backups {
+			if i == 3 {
+				body = append(body, fmt.Sprintf("  ... %d more", len(snap.Backups)-i))
+				break
+			}
+			created := b.Created
+			if t, err := time.Parse(time.RFC3339, b.Created); err == nil {
+				created = t.Local().Format("2006-01-02 15:04")
+			}
+			body = append(body, fmt.Sprintf("  v%-12s %-17s %-10s %s", b.Version, created, b.SizeHuman, b.Reason))
+		}
+
+		body = append(body, "", "Recent logs")
+		// Show as many of the newest log lines as fit above the footer
+		room := rows - 2 - len(body)
+		logs := snap.Logs
+		if room < 0 {
+			room = 0
+		}
+		if len(logs) > room {
+			logs = logs[len(logs)-room:]
+		}
+		for _, line := range logs {
+			body = append(body, "  "+line)
+		}
+	}
+
+	header := fmt.Sprintf("%s  (every %s, %s)", title, interval, now.Format("15:04:05"))
+	footer := "[r] restart  [b] backup  [l] logs  [q] quit"
+	if message != "" {
+		footer += "   " + message
+	}
+
+	lines := []string{ansiReverse + padLine(truncateLine(header, cols), cols) + ansiReset}
+	for _, line := range body {
+		if len(lines) == rows-1 {
+			break
+		}
+		lines = append(lines, truncateLine(line, cols))
+	}
+	for len(lines) < rows-1 {
+		lines = append(lines, "")
+	}
+	return append(lines, ansiReverse+padLine(truncateLine(footer, cols), cols)+ansiReset)
+}
+
+// collectDashboard gathers the data for one dashboard refresh
+func collectDashboard() dashboardSnapshot {
+	snap := dashboardSnapshot{Status: collectStatus()}
+	snap.Backups, _ = listBackups("/var/lib/convex/backups")
+
+	output, _ := exec.Command("journalctl", "-u", "convex-backend", "-n", fmt.Sprint(dashboardLogLines), "--no-pager", "-o", "short-iso").Output()
+	for _, line := range strings.Split(strings.TrimRight(redactKnownSecrets(string(output)), "\n"), "\n") {
+		if line != "" {
+			snap.Logs = append(snap.Logs, line)
+		}
+	}
+	return snap
+}
+
+// readKeys forwards single key presses from stdin
+func readKeys(keys chan<- byte) {
+	buf := make([]byte, 1)
+	for {
+		if n, err := os.Stdin.Read(buf); err != nil {
+			return
+		} else if n == 1 {
+			keys <- buf[0]
+		}
+	}
+}
+
+func drainSignals(sigs <-chan os.Signal) {
+	for {
+		select {
+		case <-sigs:
+		default:
+			return
+		}
+	}
+}
+
+func padLine(line string, width int) string {
+	if n := len([]rune(line)); n < width {
+		return line + strings.Repeat(" ", width-n)
+	}
+	return line
+}
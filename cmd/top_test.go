@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func testDashboardSnapshot() *dashboardSnapshot {
+	snap := &dashboardSnapshot{
+		Status: StatusOutput{
+			Installed:      true,
+			Manifest:       &Manifest{Name: "My Backend", Version: "1.5.0"},
+			ServiceStatus:  "active",
+			ServiceEnabled: true,
+			Health:         "healthy",
+			Process:        &ServiceProcess{PID: 4242, UptimeSeconds: 7200, MemoryBytes: 512 << 20, ListeningPorts: []int{3210, 3211}},
+			Storage:        &StorageStatus{DatabaseBytes: 1 << 20, DiskFreeBytes: 10 << 30, DiskTotalBytes: 20 << 30, DiskFreePercentage: 50},
+		},
+		Backups: []BackupInfo{{Version: "1.4.0", Created: "2025-01-12T10:00:00Z", SizeHuman: "1.2 GB", Reason: "upgrade"}},
+	}
+	for i := 0; i < 40; i++ {
+		snap.Logs = append(snap.Logs, fmt.Sprintf("log line %d", i))
+	}
+	return snap
+}
+
+func TestRenderDashboard(t *testing.T) {
+	lines := renderDashboard(testDashboardSnapshot(), "Backup created", 2*time.Second, time.Now(), 20, 120)
+	if len(lines) != 20 {
+		t.Fatalf("expected 20 lines, got %d", len(lines))
+	}
+
+	screen := strings.Join(lines, "\n")
+	for _, want := range []string{"My Backend v1.5.0", "active (enabled)", "PID 4242", "ports 3210,3211", "v1.4.0", "log line 39", "Backup created"} {
+		if !strings.Contains(screen, want) {
+			t.Errorf("dashboard is missing %q:\n%s", want, screen)
+		}
+	}
+	if strings.Contains(screen, "log line 0\n") {
+		t.Error("expected old log lines to be cut to fit the screen")
+	}
+
+	for _, line := range renderDashboard(testDashboardSnapshot(), "", 2*time.Second, time.Now(), 20, 60) {
+		plain := strings.NewReplacer(ansiReverse, "", ansiReset, "").Replace(line)
+		if utf8.RuneCountInString(plain) > 60 {
+			t.Errorf("line wider than the terminal: %q", plain)
+		}
+	}
+}
+
+func TestRenderDashboard_Collecting(t *testing.T) {
+	lines := renderDashboard(nil, "", 2*time.Second, time.Now(), 10, 80)
+	if len(lines) != 10 || !strings.Contains(strings.Join(lines, "\n"), "Collecting status...") {
+		t.Errorf("unexpected placeholder screen: %q", lines)
+	}
+}
+
+func TestDashboardConfirmation(t *testing.T) {
+	d := &dashboard{pending: "restart"}
+	if d.handleKey('n') {
+		t.Fatal("declining should not quit")
+	}
+	if d.pending != "" || d.message != "restart cancelled" {
+		t.Errorf("unexpected state after declining: %+v", d)
+	}
+	if !d.handleKey('q') {
+		t.Error("q should quit")
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"bufio"
 	"fmt"
 	"os"
-	"os/exec"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -50,8 +49,9 @@ func runUninstall(cmd *cobra.Command, args []string) error {
 
 	// Stop and disable service
 	printInfo("Stopping service...")
-	exec.Command("systemctl", "stop", "convex-backend").Run()
-	exec.Command("systemctl", "disable", "convex-backend").Run()
+	backendService.stop()
+	backendService.disable(false)
+	exporterService.disable(true)
 
 	// Remove files
 	printInfo("Removing files...")
@@ -59,6 +59,7 @@ func runUninstall(cmd *cobra.Command, args []string) error {
 	filesToRemove := []string{
 		"/usr/local/bin/convex-backend",
 		"/etc/systemd/system/convex-backend.service",
+		exporterUnitPath,
 	}
 
 	for _, f := range filesToRemove {
@@ -80,7 +81,7 @@ func runUninstall(cmd *cobra.Command, args []string) error {
 	}
 
 	// Reload systemd
-	exec.Command("systemctl", "daemon-reload").Run()
+	daemonReload()
 
 	printSuccess("Convex backend uninstalled")
 	fmt.Println()
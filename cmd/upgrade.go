@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"sort"
 	"strconv"
@@ -13,35 +12,82 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// backupReasonManual marks backups taken on request. They are kept until
+// removed by hand: pruning and rollback's default choice skip them.
+const backupReasonManual = "manual"
+
 // BackupMeta represents the meta.json for a backup
 type BackupMeta struct {
-	Version     string `json:"version"`
-	Timestamp   string `json:"timestamp"`
-	Reason      string `json:"reason"`
-	FromVersion string `json:"fromVersion"`
-	ToVersion   string `json:"toVersion"`
+	Version     string      `json:"version"`
+	Timestamp   string      `json:"timestamp"`
+	Reason      string      `json:"reason"`
+	FromVersion string      `json:"fromVersion"`
+	ToVersion   string      `json:"toVersion"`
+	Outcome     string      `json:"outcome,omitempty"`
+	Soak        *SoakResult `json:"soak,omitempty"`
 }
 
 var (
 	upgradeBundlePath string
 	upgradeForce      bool
+	upgradePlanDir    string
+	upgradeBundlesDir string
+	upgradeBlueGreen  bool
+	upgradeStagePort  int
+
+	upgradeSoak             time.Duration
+	upgradeSoakMaxRestarts  int
+	upgradeSoakMaxErrorRate float64
 )
 
 var upgradeCmd = &cobra.Command{
 	Use:   "upgrade",
 	Short: "Upgrade Convex backend from a new bundle",
-	Long:  `Upgrade Convex backend to a new version from a bundle with automatic backup.`,
-	RunE:  runUpgrade,
+	Long: `Upgrade Convex backend to a new version from a bundle with automatic backup.
+
+Bundles may restrict which installed versions they can be upgraded from
+(manifest "upgradeFrom"). Use --plan with a directory of bundles to find the
+chain of intermediate upgrades required to reach the target version.
+
+With --blue-green the new binary is first started on a temporary port against a
+copy of the data and must pass the health checks before the running backend is
+stopped, so a broken release never causes downtime.
+
+With --soak the upgraded backend is watched for the given duration after it
+passes its health checks. If systemd restarts it more often than
+--soak-max-restarts or the share of failed health probes exceeds
+--soak-max-error-rate, the upgrade is rolled back automatically. The outcome is
+recorded in the backup's meta.json.
+
+Bundles whose manifest declares "type": "apps" carry app deployments only.
+Their functions package is pushed into the running backend through its admin
+API; the backend binary and the database, including user data, are kept.`,
+	RunE: runUpgrade,
 }
 
 func init() {
 	rootCmd.AddCommand(upgradeCmd)
 	upgradeCmd.Flags().StringVarP(&upgradeBundlePath, "bundle", "b", "", "Path to the new bundle directory (required)")
 	upgradeCmd.Flags().BoolVarP(&upgradeForce, "force", "f", false, "Force upgrade even if same version")
-	upgradeCmd.MarkFlagRequired("bundle")
+	upgradeCmd.Flags().DurationVar(&flagHealthTimeout, "health-timeout", 0, "How long to wait for health checks to pass (default from health config, 30s)")
+	upgradeCmd.Flags().BoolVar(&upgradeBlueGreen, "blue-green", false, "Validate the new version side by side before stopping the running backend")
+	upgradeCmd.Flags().IntVar(&upgradeStagePort, "staging-port", 3310, "Port for the side-by-side backend (site proxy uses port+1)")
+	upgradeCmd.Flags().DurationVar(&upgradeSoak, "soak", 0, "Monitor the upgraded backend for this long and roll back on crash loops (e.g. 15m)")
+	upgradeCmd.Flags().IntVar(&upgradeSoakMaxRestarts, "soak-max-restarts", 2, "Service restarts allowed during the soak period")
+	upgradeCmd.Flags().Float64Var(&upgradeSoakMaxErrorRate, "soak-max-error-rate", 0.25, "Share of failed health probes allowed during the soak period (0-1)")
+	upgradeCmd.Flags().StringVar(&upgradePlanDir, "plan", "", "Show the upgrade chain using the bundles in this directory instead of upgrading")
+	upgradeCmd.Flags().StringVar(&upgradeBundlesDir, "bundles-dir", "", "Directory of bundles used to name the intermediate versions when the bundle cannot be upgraded to directly (default: the bundle's parent directory)")
 }
 
-func runUpgrade(cmd *cobra.Command, args []string) error {
+func runUpgrade(cmd *cobra.Command, args []string) (retErr error) {
+	if upgradePlanDir != "" {
+		return runUpgradePlan(upgradePlanDir)
+	}
+
+	if upgradeBundlePath == "" {
+		return fmt.Errorf("required flag(s) \"bundle\" not set")
+	}
+
 	// Pre-flight checks
 	if err := checkRoot(); err != nil {
 		return err
@@ -58,14 +104,16 @@ func runUpgrade(cmd *cobra.Command, args []string) error {
 	}
 
 	// Validate new bundle
-	if err := validateBundle(upgradeBundlePath); err != nil {
+	// Upgrades keep the installed credentials
+	newManifest, err := validateBundle(upgradeBundlePath, true)
+	if err != nil {
 		return fmt.Errorf("invalid bundle: %w", err)
 	}
-
-	// Read new manifest
-	newManifest, err := readManifest(filepath.Join(upgradeBundlePath, "manifest.json"))
-	if err != nil {
-		return fmt.Errorf("failed to read new manifest: %w", err)
+	if err := checkManifestCompatibility(newManifest); err != nil {
+		return err
+	}
+	if newManifest.isAppsOnly() && upgradeBlueGreen {
+		return fmt.Errorf("--blue-green cannot be used with an apps-only bundle")
 	}
 
 	// Compare versions
@@ -73,6 +121,17 @@ func runUpgrade(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("already at version %s. Use --force to upgrade anyway", currentManifest.Version)
 	}
 
+	// Check the bundle accepts the installed version as an upgrade source
+	if currentManifest.Version != newManifest.Version {
+		bundlesDir := upgradeBundlesDir
+		if bundlesDir == "" {
+			bundlesDir = filepath.Dir(filepath.Clean(upgradeBundlePath))
+		}
+		if err := checkUpgradePath(currentManifest, newManifest, upgradeBundlePath, bundlesDir); err != nil {
+			return err
+		}
+	}
+
 	printInfo("Upgrading from v%s to v%s", currentManifest.Version, newManifest.Version)
 
 	// Create backup
@@ -82,49 +141,144 @@ func runUpgrade(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create backup: %w", err)
 	}
 
-	// Stop service
-	printInfo("Stopping service...")
-	if err := exec.Command("systemctl", "stop", "convex-backend").Run(); err != nil {
-		return fmt.Errorf("failed to stop service: %w", err)
-	}
+	hooks := &hookContext{
+		Operation:   "upgrade",
+		FromVersion: currentManifest.Version,
+		ToVersion:   newManifest.Version,
+		BundlePath:  upgradeBundlePath,
+		BackupDir:   backupDir,
+	}
+	defer func() { hooks.runPost(retErr) }()
+
+	// Record the outcome in the backup metadata
+	var soakResult *SoakResult
+	defer func() {
+		outcome := hookOutcomeSuccess
+		if retErr != nil {
+			outcome = hookOutcomeFailure
+			if hooks.RolledBack {
+				outcome = hookOutcomeRolledBack
+			}
+		}
+		updateBackupMeta(backupDir, func(meta *BackupMeta) {
+			meta.Outcome = outcome
+			meta.Soak = soakResult
+		})
+	}()
 
-	// Install new version
-	printInfo("Installing new version...")
-	if err := installNewVersion(upgradeBundlePath); err != nil {
-		// Auto-rollback on failure
-		printError("Upgrade failed: %v", err)
-		printInfo("Rolling back to previous version...")
-		if rbErr := performRollback(backupDir); rbErr != nil {
-			return fmt.Errorf("rollback also failed: %w (original error: %v)", rbErr, err)
+	// Blue/green: prove the new version works before touching the running one
+	if upgradeBlueGreen {
+		printInfo("Validating new version side by side on port %d...", upgradeStagePort)
+		if err := validateStaged(upgradeBundlePath, backupDir, newManifest, upgradeStagePort); err != nil {
+			return fmt.Errorf("blue/green validation failed, v%s is still running: %w", currentManifest.Version, err)
 		}
-		return fmt.Errorf("upgrade failed, rolled back to v%s: %w", currentManifest.Version, err)
+		printInfo("New version passed health checks, cutting over...")
 	}
 
-	// Start service
-	printInfo("Starting service...")
-	if err := exec.Command("systemctl", "start", "convex-backend").Run(); err != nil {
-		// Auto-rollback on failure
-		printError("Failed to start service: %v", err)
-		printInfo("Rolling back to previous version...")
-		if rbErr := performRollback(backupDir); rbErr != nil {
-			return fmt.Errorf("rollback also failed: %w (original error: %v)", rbErr, err)
+	// Pre-upgrade hooks (e.g. draining traffic); nothing has changed yet, so a
+	// failure just aborts with the running version untouched
+	if err := hooks.runPre(); err != nil {
+		return fmt.Errorf("pre-upgrade hook failed, v%s is still running: %w", currentManifest.Version, err)
+	}
+
+	if newManifest.isAppsOnly() {
+		// Push the new functions into the running backend; the push is atomic
+		// so a failure leaves the previous functions deployed
+		printInfo("Pushing functions to the running backend...")
+		if err := pushAppsBundle(upgradeBundlePath); err != nil {
+			return fmt.Errorf("apps upgrade failed, v%s is still running: %w", currentManifest.Version, err)
+		}
+
+		if err := writeAppsManifest(currentManifest, newManifest); err != nil {
+			printError("Upgrade failed: %v", err)
+			printInfo("Rolling back to previous version...")
+			backendService.stop()
+			if rbErr := performRollback(backupDir); rbErr != nil {
+				return fmt.Errorf("rollback also failed: %w (original error: %v)", rbErr, err)
+			}
+			hooks.RolledBack = true
+			return fmt.Errorf("upgrade failed, rolled back to v%s: %w", currentManifest.Version, err)
+		}
+	} else {
+		// Stop service
+		printInfo("Stopping service...")
+		if err := backendService.stop(); err != nil {
+			return fmt.Errorf("failed to stop service: %w", err)
+		}
+
+		// Install new version
+		printInfo("Installing new version...")
+		if err := installNewVersion(upgradeBundlePath); err != nil {
+			// Auto-rollback on failure
+			printError("Upgrade failed: %v", err)
+			printInfo("Rolling back to previous version...")
+			if rbErr := performRollback(backupDir); rbErr != nil {
+				return fmt.Errorf("rollback also failed: %w (original error: %v)", rbErr, err)
+			}
+			hooks.RolledBack = true
+			return fmt.Errorf("upgrade failed, rolled back to v%s: %w", currentManifest.Version, err)
+		}
+
+		// Start service
+		printInfo("Starting service...")
+		if err := backendService.start(); err != nil {
+			// Auto-rollback on failure
+			printError("Failed to start service: %v", err)
+			printInfo("Rolling back to previous version...")
+			if rbErr := performRollback(backupDir); rbErr != nil {
+				return fmt.Errorf("rollback also failed: %w (original error: %v)", rbErr, err)
+			}
+			hooks.RolledBack = true
+			return fmt.Errorf("service failed to start, rolled back to v%s: %w", currentManifest.Version, err)
 		}
-		return fmt.Errorf("service failed to start, rolled back to v%s: %w", currentManifest.Version, err)
 	}
 
 	// Health check with auto-rollback
 	printInfo("Waiting for backend to be ready...")
-	if err := waitForHealth("http://localhost:3210", 30*time.Second); err != nil {
+	if err := waitForHealth(); err != nil {
 		printError("Health check failed: %v", err)
 		showServiceLogs()
 		printInfo("Rolling back to previous version...")
-		exec.Command("systemctl", "stop", "convex-backend").Run()
+		backendService.stop()
 		if rbErr := performRollback(backupDir); rbErr != nil {
 			return fmt.Errorf("rollback also failed: %w (original error: %v)", rbErr, err)
 		}
+		hooks.RolledBack = true
 		return fmt.Errorf("health check failed, rolled back to v%s: %w", currentManifest.Version, err)
 	}
 
+	// Soak period with auto-rollback
+	if upgradeSoak > 0 {
+		printInfo("Monitoring new version for %s...", upgradeSoak)
+		soakResult, err = soakUpgrade(upgradeSoak, soakThresholds{
+			maxRestarts:  upgradeSoakMaxRestarts,
+			maxErrorRate: upgradeSoakMaxErrorRate,
+			minProbes:    5,
+		})
+		if err != nil {
+			// An unmonitored upgrade cannot be vouched for; treat it as a failed soak
+			soakResult = &SoakResult{
+				Duration:    upgradeSoak.String(),
+				Reason:      fmt.Sprintf("monitoring failed: %v", err),
+				CompletedAt: time.Now().UTC().Format(time.RFC3339),
+			}
+		}
+		if !soakResult.Passed {
+			printError("Soak failed: %s", soakResult.Reason)
+			showServiceLogs()
+			printInfo("Rolling back to previous version...")
+			backendService.stop()
+			if rbErr := performRollback(backupDir); rbErr != nil {
+				return fmt.Errorf("rollback also failed: %w (soak failed: %s)", rbErr, soakResult.Reason)
+			}
+			hooks.RolledBack = true
+			return fmt.Errorf("soak failed, rolled back to v%s: %s", currentManifest.Version, soakResult.Reason)
+		}
+		printSuccess("Soak passed (%d restarts, %.0f%% failed probes)", soakResult.Restarts, soakResult.ErrorRate*100)
+	}
+
+	recordInstalledFilesOrWarn("upgrade")
+
 	// Prune old backups (only after successful upgrade)
 	printInfo("Pruning old backups...")
 	pruneBackups()
@@ -178,6 +332,43 @@ func createBackup(backupDir, fromVersion, toVersion string) error {
 	return nil
 }
 
+// createManualBackup backs up the installed version outside of an upgrade.
+// Each manual backup gets its own timestamped directory so it never replaces
+// the backup an upgrade made of the same version. The service is stopped
+// during the copy so the SQLite database and its WAL are consistent.
+func createManualBackup() (string, error) {
+	manifest, err := readManifest("/var/lib/convex/manifest.json")
+	if err != nil {
+		return "", fmt.Errorf("failed to read installed manifest: %w", err)
+	}
+
+	wasActive := backendService.activeState() == "active"
+	if wasActive {
+		if err := backendService.stop(); err != nil {
+			return "", fmt.Errorf("failed to stop service for a consistent copy: %w", err)
+		}
+	}
+
+	backupDir := filepath.Join("/var/lib/convex/backups", fmt.Sprintf("v%s-%s", manifest.Version, time.Now().UTC().Format("20060102T150405Z")))
+	backupErr := createBackup(backupDir, manifest.Version, "")
+	if backupErr == nil {
+		backupErr = updateBackupMeta(backupDir, func(meta *BackupMeta) { meta.Reason = backupReasonManual })
+	}
+	if backupErr != nil {
+		os.RemoveAll(backupDir)
+	}
+
+	if wasActive {
+		if err := backendService.start(); err != nil {
+			return "", fmt.Errorf("failed to start service after the backup: %w", err)
+		}
+	}
+	if backupErr != nil {
+		return "", backupErr
+	}
+	return backupDir, nil
+}
+
 func installNewVersion(bundlePath string) error {
 	// Copy new binary
 	if err := copyFile(filepath.Join(bundlePath, "backend"), "/usr/local/bin/convex-backend"); err != nil {
@@ -198,6 +389,58 @@ func installNewVersion(bundlePath string) error {
 	return nil
 }
 
+// pushAppsBundle deploys the functions package of an apps-only bundle to the
+// running backend
+func pushAppsBundle(bundlePath string) error {
+	pkg, err := loadFunctionsPackage(filepath.Join(bundlePath, "functions"))
+	if err != nil {
+		return err
+	}
+
+	client, err := newAdminClient()
+	if err != nil {
+		return err
+	}
+
+	printInfo("Deploying %d modules...", len(pkg.Modules))
+	return client.pushFunctions(pkg)
+}
+
+// writeAppsManifest records an apps-only upgrade in the installed manifest.
+func writeAppsManifest(current, next *Manifest) error {
+	merged := mergeAppsManifest(current, next)
+
+	original, err := os.ReadFile("/var/lib/convex/manifest.json")
+	if err != nil {
+		return fmt.Errorf("failed to read manifest: %w", err)
+	}
+	data, err := marshalManifest(original, merged)
+	if err != nil {
+		return fmt.Errorf("failed to serialize manifest: %w", err)
+	}
+	if err := os.WriteFile("/var/lib/convex/manifest.json", data, 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+// mergeAppsManifest applies an apps bundle to the installed manifest. The
+// backend binary is unchanged, so its version, platform, bundle type and
+// upgrade constraints are kept and the apps bundle version is recorded in
+// appsVersion.
+func mergeAppsManifest(current, next *Manifest) *Manifest {
+	merged := *current
+	merged.Name = next.Name
+	merged.AppsVersion = next.Version
+	if next.Apps != nil {
+		merged.Apps = next.Apps
+	}
+	if next.Health != nil {
+		merged.Health = next.Health
+	}
+	return &merged
+}
+
 func performRollback(backupDir string) error {
 	// Copy binary back
 	if err := copyFile(filepath.Join(backupDir, "convex-backend"), "/usr/local/bin/convex-backend"); err != nil {
@@ -223,7 +466,7 @@ func performRollback(backupDir string) error {
 	}
 
 	// Start service
-	if err := exec.Command("systemctl", "start", "convex-backend").Run(); err != nil {
+	if err := backendService.start(); err != nil {
 		return fmt.Errorf("failed to start service after rollback: %w", err)
 	}
 
@@ -267,6 +510,9 @@ func pruneBackups() {
 		if err := json.Unmarshal(data, &meta); err != nil {
 			continue
 		}
+		if meta.Reason == backupReasonManual {
+			continue
+		}
 
 		ts, err := time.Parse(time.RFC3339, meta.Timestamp)
 		if err != nil {
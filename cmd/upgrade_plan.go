@@ -0,0 +1,327 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// UpgradePlanStep represents a single upgrade in an upgrade plan
+type UpgradePlanStep struct {
+	FromVersion string `json:"fromVersion"`
+	ToVersion   string `json:"toVersion"`
+	Bundle      string `json:"bundle"`
+}
+
+// UpgradePlanOutput represents JSON output for upgrade --plan
+type UpgradePlanOutput struct {
+	FromVersion string            `json:"fromVersion"`
+	ToVersion   string            `json:"toVersion"`
+	Steps       []UpgradePlanStep `json:"steps"`
+}
+
+// versionComparator is a single comparison such as ">=1.2.0"
+type versionComparator struct {
+	op      string
+	version semVersion
+}
+
+// versionRange is a set of comparators that must all match, e.g. ">=1.2.0 <1.4.0"
+type versionRange []versionComparator
+
+// parseVersionRange parses a space-separated list of comparators. A bare
+// version means an exact match.
+func parseVersionRange(s string) (versionRange, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty version range")
+	}
+
+	var r versionRange
+	for _, f := range fields {
+		op := "="
+		for _, candidate := range []string{">=", "<=", ">", "<", "="} {
+			if strings.HasPrefix(f, candidate) {
+				op = candidate
+				f = strings.TrimPrefix(f, candidate)
+				break
+			}
+		}
+
+		v, err := parseVersion(f)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version range %q: %w", s, err)
+		}
+		r = append(r, versionComparator{op: op, version: v})
+	}
+
+	return r, nil
+}
+
+func (r versionRange) matches(v semVersion) bool {
+	for _, c := range r {
+		cmp := v.compare(c.version)
+		var ok bool
+		switch c.op {
+		case ">=":
+			ok = cmp >= 0
+		case "<=":
+			ok = cmp <= 0
+		case ">":
+			ok = cmp > 0
+		case "<":
+			ok = cmp < 0
+		default:
+			ok = cmp == 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// canUpgradeFrom reports whether the manifest allows upgrading from the given
+// version. Manifests without upgradeFrom constraints accept any version.
+func (m *Manifest) canUpgradeFrom(version string) (bool, error) {
+	if len(m.UpgradeFrom) == 0 {
+		return true, nil
+	}
+
+	v, err := parseVersion(version)
+	if err != nil {
+		return false, err
+	}
+
+	for _, s := range m.UpgradeFrom {
+		r, err := parseVersionRange(s)
+		if err != nil {
+			return false, err
+		}
+		if r.matches(v) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// checkUpgradePath verifies that the new bundle accepts the installed version
+// as an upgrade source. If it does not, the bundles in bundlesDir are used to
+// name the intermediate versions to upgrade through.
+func checkUpgradePath(current, next *Manifest, bundlePath, bundlesDir string) error {
+	ok, err := next.canUpgradeFrom(current.Version)
+	if err != nil {
+		return fmt.Errorf("cannot check upgrade path: %w", err)
+	}
+	if ok {
+		return nil
+	}
+
+	msg := fmt.Sprintf("v%s cannot be upgraded to directly from v%s. It requires an installed version matching: %s. ",
+		next.Version, current.Version, strings.Join(next.UpgradeFrom, " or "))
+
+	if bundlesDir != "" {
+		target := filepath.Clean(bundlePath)
+		if bundles, err := bundlesWithTarget(bundlesDir, target); err == nil {
+			if steps, err := planUpgrade(current.Version, bundles, target); err == nil {
+				hops := make([]string, 0, len(steps))
+				for _, step := range steps[:len(steps)-1] {
+					hops = append(hops, fmt.Sprintf("v%s (%s)", step.ToVersion, step.Bundle))
+				}
+				return fmt.Errorf("%sUpgrade through %s first", msg, strings.Join(hops, ", then "))
+			}
+		}
+		return fmt.Errorf("%sNo upgrade path was found with the bundles in %s", msg, bundlesDir)
+	}
+
+	return fmt.Errorf("%sUpgrade through an intermediate version first (see 'upgrade --plan <bundles-dir>')", msg)
+}
+
+type plannedBundle struct {
+	path     string
+	manifest *Manifest
+	version  semVersion
+}
+
+// scanBundles reads the manifests of every bundle directly inside dir
+func scanBundles(dir string) ([]plannedBundle, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundles directory: %w", err)
+	}
+
+	var bundles []plannedBundle
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		manifest, err := readManifest(filepath.Join(path, "manifest.json"))
+		if err != nil {
+			continue
+		}
+
+		v, err := parseVersion(manifest.Version)
+		if err != nil {
+			printInfo("Warning: skipping %s: %v", path, err)
+			continue
+		}
+
+		bundles = append(bundles, plannedBundle{path: path, manifest: manifest, version: v})
+	}
+
+	return bundles, nil
+}
+
+// planUpgrade finds the shortest chain of bundles that leads from the
+// installed version to the target version, preferring the largest jump
+// at each step when several chains have the same length.
+func planUpgrade(fromVersion string, bundles []plannedBundle, target string) ([]UpgradePlanStep, error) {
+	from, err := parseVersion(fromVersion)
+	if err != nil {
+		return nil, fmt.Errorf("installed version: %w", err)
+	}
+
+	// Highest version first so breadth-first search tries big jumps first
+	sort.Slice(bundles, func(i, j int) bool {
+		return bundles[i].version.compare(bundles[j].version) > 0
+	})
+
+	targetIdx := -1
+	for i, b := range bundles {
+		if (target == "" && targetIdx == -1) || (target != "" && b.path == target) {
+			targetIdx = i
+		}
+	}
+	if targetIdx == -1 {
+		return nil, fmt.Errorf("no target bundle found")
+	}
+	if bundles[targetIdx].version.compare(from) <= 0 {
+		return nil, fmt.Errorf("already at v%s, target bundle is v%s", from, bundles[targetIdx].version)
+	}
+
+	// prev[i] is the bundle index we came from, -1 for the installed version
+	prev := make([]int, len(bundles))
+	visited := make([]bool, len(bundles))
+	type node struct {
+		idx     int
+		version semVersion
+	}
+	queue := []node{{idx: -1, version: from}}
+
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+
+		for i, b := range bundles {
+			if visited[i] || b.version.compare(cur.version) <= 0 {
+				continue
+			}
+			if b.version.compare(bundles[targetIdx].version) > 0 {
+				continue
+			}
+			ok, err := b.manifest.canUpgradeFrom(cur.version.String())
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", b.path, err)
+			}
+			if !ok {
+				continue
+			}
+
+			visited[i] = true
+			prev[i] = cur.idx
+			if i == targetIdx {
+				return buildUpgradeSteps(bundles, prev, targetIdx, from), nil
+			}
+			queue = append(queue, node{idx: i, version: b.version})
+		}
+	}
+
+	return nil, fmt.Errorf("no upgrade path from v%s to v%s with the available bundles", from, bundles[targetIdx].version)
+}
+
+func buildUpgradeSteps(bundles []plannedBundle, prev []int, targetIdx int, from semVersion) []UpgradePlanStep {
+	var chain []int
+	for i := targetIdx; i != -1; i = prev[i] {
+		chain = append([]int{i}, chain...)
+	}
+
+	steps := make([]UpgradePlanStep, 0, len(chain))
+	fromVersion := from.String()
+	for _, i := range chain {
+		steps = append(steps, UpgradePlanStep{
+			FromVersion: fromVersion,
+			ToVersion:   bundles[i].manifest.Version,
+			Bundle:      bundles[i].path,
+		})
+		fromVersion = bundles[i].manifest.Version
+	}
+	return steps
+}
+
+// bundlesWithTarget scans bundlesDir and adds the target bundle if it lives
+// elsewhere
+func bundlesWithTarget(bundlesDir, target string) ([]plannedBundle, error) {
+	bundles, err := scanBundles(bundlesDir)
+	if err != nil {
+		return nil, err
+	}
+	if target == "" || filepath.Dir(target) == filepath.Clean(bundlesDir) {
+		return bundles, nil
+	}
+
+	manifest, err := readManifest(filepath.Join(target, "manifest.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read target manifest: %w", err)
+	}
+	v, err := parseVersion(manifest.Version)
+	if err != nil {
+		return nil, fmt.Errorf("target bundle: %w", err)
+	}
+	return append(bundles, plannedBundle{path: target, manifest: manifest, version: v}), nil
+}
+
+func runUpgradePlan(bundlesDir string) error {
+	currentManifest, err := readManifest("/var/lib/convex/manifest.json")
+	if err != nil {
+		return fmt.Errorf("Convex backend is not installed. Use 'install' first")
+	}
+
+	target := ""
+	if upgradeBundlePath != "" {
+		target = filepath.Clean(upgradeBundlePath)
+	}
+	bundles, err := bundlesWithTarget(bundlesDir, target)
+	if err != nil {
+		return err
+	}
+
+	steps, err := planUpgrade(currentManifest.Version, bundles, target)
+	if err != nil {
+		return err
+	}
+
+	output := UpgradePlanOutput{
+		FromVersion: currentManifest.Version,
+		ToVersion:   steps[len(steps)-1].ToVersion,
+		Steps:       steps,
+	}
+
+	if flagJSON {
+		return printJSON(output)
+	}
+
+	fmt.Printf("Upgrade plan from v%s to v%s\n", output.FromVersion, output.ToVersion)
+	fmt.Println()
+	for i, step := range steps {
+		fmt.Printf("  %d. v%s -> v%s  (%s)\n", i+1, step.FromVersion, step.ToVersion, step.Bundle)
+	}
+	fmt.Println()
+	fmt.Println("Run each step with:")
+	fmt.Println("  sudo convex-backend-ops upgrade --bundle <bundle>")
+
+	return nil
+}
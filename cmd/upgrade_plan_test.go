@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVersionRangeMatches(t *testing.T) {
+	tests := []struct {
+		rng     string
+		version string
+		want    bool
+	}{
+		{">=1.2.0 <1.4.0", "1.2.0", true},
+		{">=1.2.0 <1.4.0", "1.3.9", true},
+		{">=1.2.0 <1.4.0", "1.4.0", false},
+		{">=1.2.0 <1.4.0", "1.1.9", false},
+		{"1.5.0", "1.5.0", true},
+		{"=1.5.0", "1.5.1", false},
+		{">1.0.0", "1.0.0", false},
+		{"<=2.0.0", "2.0.0", true},
+	}
+
+	for _, tt := range tests {
+		r, err := parseVersionRange(tt.rng)
+		if err != nil {
+			t.Fatalf("parseVersionRange(%q): %v", tt.rng, err)
+		}
+		v, _ := parseVersion(tt.version)
+		if got := r.matches(v); got != tt.want {
+			t.Errorf("%q matches %q = %v, want %v", tt.rng, tt.version, got, tt.want)
+		}
+	}
+
+	if _, err := parseVersionRange(">=banana"); err == nil {
+		t.Error("expected error for invalid range")
+	}
+}
+
+func TestCheckUpgradePath(t *testing.T) {
+	next := &Manifest{Version: "2.0.0", UpgradeFrom: []string{">=1.5.0 <2.0.0"}}
+
+	if err := checkUpgradePath(&Manifest{Version: "1.6.0"}, next, "", ""); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	err := checkUpgradePath(&Manifest{Version: "1.0.0"}, next, "", "")
+	if err == nil || !strings.Contains(err.Error(), ">=1.5.0 <2.0.0") {
+		t.Errorf("expected error listing required versions, got %v", err)
+	}
+
+	if err := checkUpgradePath(&Manifest{Version: "0.1.0"}, &Manifest{Version: "3.0.0"}, "", ""); err != nil {
+		t.Errorf("unconstrained bundle should accept any version: %v", err)
+	}
+}
+
+func TestPlanUpgrade(t *testing.T) {
+	dir := t.TempDir()
+	bundles := map[string]string{
+		"v1.5.0": `{"name":"App","version":"1.5.0"}`,
+		"v1.6.0": `{"name":"App","version":"1.6.0","upgradeFrom":[">=1.0.0"]}`,
+		"v2.0.0": `{"name":"App","version":"2.0.0","upgradeFrom":[">=1.6.0 <2.0.0"]}`,
+		"v2.1.0": `{"name":"App","version":"2.1.0","upgradeFrom":[">=2.0.0"]}`,
+	}
+	for name, manifest := range bundles {
+		os.MkdirAll(filepath.Join(dir, name), 0755)
+		os.WriteFile(filepath.Join(dir, name, "manifest.json"), []byte(manifest), 0644)
+	}
+
+	scanned, err := scanBundles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps, err := planUpgrade("1.0.0", scanned, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var versions []string
+	for _, s := range steps {
+		versions = append(versions, s.ToVersion)
+	}
+	if got := strings.Join(versions, ","); got != "1.6.0,2.0.0,2.1.0" {
+		t.Errorf("unexpected plan %s", got)
+	}
+
+	steps, err = planUpgrade("1.0.0", scanned, filepath.Join(dir, "v1.6.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 1 || steps[0].ToVersion != "1.6.0" {
+		t.Errorf("unexpected plan to explicit target: %+v", steps)
+	}
+
+	if _, err := planUpgrade("1.0.0", scanned[:0], ""); err == nil {
+		t.Error("expected error without bundles")
+	}
+}
+
+func TestCheckUpgradePath_ListsHops(t *testing.T) {
+	dir := t.TempDir()
+	bundles := map[string]string{
+		"v1.6.0": `{"name":"App","version":"1.6.0","upgradeFrom":[">=1.0.0"]}`,
+		"v2.0.0": `{"name":"App","version":"2.0.0","upgradeFrom":[">=1.6.0 <2.0.0"]}`,
+	}
+	for name, manifest := range bundles {
+		os.MkdirAll(filepath.Join(dir, name), 0755)
+		os.WriteFile(filepath.Join(dir, name, "manifest.json"), []byte(manifest), 0644)
+	}
+
+	next, err := readManifest(filepath.Join(dir, "v2.0.0", "manifest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = checkUpgradePath(&Manifest{Version: "1.0.0"}, next, filepath.Join(dir, "v2.0.0"), dir)
+	if err == nil || !strings.Contains(err.Error(), "Upgrade through v1.6.0 ("+filepath.Join(dir, "v1.6.0")+") first") {
+		t.Errorf("expected the intermediate version in the error, got %v", err)
+	}
+
+	err = checkUpgradePath(&Manifest{Version: "0.5.0"}, &Manifest{Version: "3.0.0", UpgradeFrom: []string{">=2.5.0"}}, filepath.Join(dir, "v3.0.0"), dir)
+	if err == nil || !strings.Contains(err.Error(), "No upgrade path was found") {
+		t.Errorf("expected no path to be found, got %v", err)
+	}
+}
@@ -0,0 +1,465 @@
+package cmd
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// installedFilesPath holds the checksums of the files install and upgrade wrote
+var installedFilesPath = "/var/lib/convex/installed-files.json"
+
+// trackedFiles are the installed files checked for drift. The database and
+// file storage change at runtime and the credentials are managed by the
+// credentials command, so they are not tracked.
+var trackedFiles = []string{
+	"/usr/local/bin/convex-backend",
+	"/etc/systemd/system/convex-backend.service",
+	"/etc/convex/convex.env",
+	"/var/lib/convex/manifest.json",
+}
+
+// Drift states reported by verify-install
+const (
+	driftOK       = "ok"
+	driftModified = "modified"
+	driftMissing  = "missing"
+)
+
+// InstalledFiles records the files written by the last install, upgrade,
+// rollback or credentials change
+type InstalledFiles struct {
+	RecordedAt string          `json:"recordedAt"`
+	Operation  string          `json:"operation"`
+	Version    string          `json:"version"`
+	Files      []InstalledFile `json:"files"`
+}
+
+// InstalledFile is the recorded checksum of one installed file
+type InstalledFile struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+	Mode   string `json:"mode"`
+}
+
+// FileDrift compares one installed file with its record
+type FileDrift struct {
+	Path           string `json:"path"`
+	Status         string `json:"status"`
+	ExpectedSHA256 string `json:"expectedSha256"`
+	ActualSHA256   string `json:"actualSha256,omitempty"`
+	Restored       bool   `json:"restored"`
+	RestoredFrom   string `json:"restoredFrom,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+// VerifyInstallOutput represents JSON output for the verify-install command
+type VerifyInstallOutput struct {
+	RecordedAt string      `json:"recordedAt"`
+	Operation  string      `json:"operation"`
+	Version    string      `json:"version"`
+	Files      []FileDrift `json:"files"`
+	Drifted    int         `json:"drifted"`
+}
+
+// restoreSource is a candidate for the expected content of a drifted file:
+// either a file on disk or rendered content
+type restoreSource struct {
+	name    string
+	path    string
+	content []byte
+}
+
+var (
+	verifyInstallRestore bool
+	verifyInstallBundle  string
+)
+
+var verifyInstallCmd = &cobra.Command{
+	Use:   "verify-install",
+	Short: "Detect changes to installed files",
+	Long: `Compare the installed backend binary, systemd unit, environment file and
+manifest with the checksums recorded by the last install, upgrade, rollback or
+credentials change, and report files that were modified or removed.
+
+This checks the live installation; 'verify' checks the payload embedded in a
+self-host executable.
+
+With --restore, drifted files are put back: the unit and environment file are
+re-rendered, and the binary and manifest are copied from --bundle (or the
+embedded bundle) or from a backup. A source is only used if its checksum
+matches the record. Restart the backend afterwards to run the restored binary.
+Exits non-zero if drift remains.`,
+	Args: cobra.NoArgs,
+	RunE: runVerifyInstall,
+}
+
+func init() {
+	rootCmd.AddCommand(verifyInstallCmd)
+	verifyInstallCmd.Flags().BoolVar(&verifyInstallRestore, "restore", false, "Restore drifted files to their recorded content")
+	verifyInstallCmd.Flags().StringVar(&verifyInstallBundle, "bundle", "", "Bundle to restore the binary and manifest from")
+}
+
+func runVerifyInstall(cmd *cobra.Command, args []string) error {
+	if verifyInstallRestore {
+		if err := checkRoot(); err != nil {
+			return err
+		}
+	}
+
+	record, err := readInstalledFiles(installedFilesPath)
+	if err != nil {
+		return err
+	}
+
+	output := VerifyInstallOutput{
+		RecordedAt: record.RecordedAt,
+		Operation:  record.Operation,
+		Version:    record.Version,
+		Files:      checkDrift(record),
+	}
+	for _, f := range output.Files {
+		if f.Status != driftOK {
+			output.Drifted++
+		}
+	}
+
+	if verifyInstallRestore && output.Drifted > 0 {
+		if err := confirmRestore(output.Files); err != nil {
+			return err
+		}
+
+		bundlePath := verifyInstallBundle
+		if bundlePath == "" && IsSelfHostMode() {
+			printInfo("Extracting embedded bundle...")
+			path, cleanup, err := GetEmbeddedBundlePath()
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+			bundlePath = path
+		}
+
+		output.Drifted = restoreDrift(output.Files, record, restoreCandidates(bundlePath))
+	}
+
+	if flagJSON {
+		if err := printJSON(output); err != nil {
+			return err
+		}
+	} else {
+		printDrift(output)
+	}
+
+	if output.Drifted > 0 {
+		return fmt.Errorf("%d installed file(s) differ from the record", output.Drifted)
+	}
+	return nil
+}
+
+func confirmRestore(files []FileDrift) error {
+	if flagYes {
+		return nil
+	}
+	fmt.Println("This will overwrite:")
+	for _, f := range files {
+		if f.Status != driftOK {
+			fmt.Printf("  - %s\n", f.Path)
+		}
+	}
+	fmt.Print("Type 'yes' to confirm: ")
+
+	reader := bufio.NewReader(os.Stdin)
+	input, _ := reader.ReadString('\n')
+	if strings.TrimSpace(input) != "yes" {
+		return fmt.Errorf("restore cancelled")
+	}
+	return nil
+}
+
+// restoreDrift restores each drifted file from the first candidate whose
+// checksum matches the record and returns the number still drifted
+func restoreDrift(files []FileDrift, record *InstalledFiles, candidates map[string][]restoreSource) int {
+	expected := make(map[string]InstalledFile)
+	for _, f := range record.Files {
+		expected[f.Path] = f
+	}
+
+	remaining := 0
+	unitRestored := false
+	for i := range files {
+		f := &files[i]
+		if f.Status == driftOK {
+			continue
+		}
+
+		src, ok := findRestoreSource(f.ExpectedSHA256, candidates[f.Path])
+		if !ok {
+			f.Error = "no source with the recorded checksum; pass --bundle with the installed version"
+			remaining++
+			continue
+		}
+		if err := restoreFile(f.Path, src, expected[f.Path].Mode); err != nil {
+			f.Error = err.Error()
+			remaining++
+			continue
+		}
+		f.Restored = true
+		f.RestoredFrom = src.name
+		if f.Path == "/etc/systemd/system/convex-backend.service" {
+			unitRestored = true
+		}
+	}
+
+	if unitRestored {
+		daemonReload()
+	}
+	return remaining
+}
+
+func printDrift(output VerifyInstallOutput) {
+	fmt.Printf("Recorded: %s by %s of v%s\n", output.RecordedAt, output.Operation, output.Version)
+	fmt.Println()
+
+	for _, f := range output.Files {
+		switch {
+		case f.Restored:
+			fmt.Printf("  RESTORED  %s (from %s)\n", f.Path, f.RestoredFrom)
+		case f.Status == driftOK:
+			fmt.Printf("  ok        %s\n", f.Path)
+		default:
+			fmt.Printf("  %-9s %s\n", strings.ToUpper(f.Status), f.Path)
+			fmt.Printf("            expected %s\n", f.ExpectedSHA256)
+			if f.ActualSHA256 != "" {
+				fmt.Printf("            actual   %s\n", f.ActualSHA256)
+			}
+		}
+		if f.Error != "" {
+			fmt.Printf("            %s\n", f.Error)
+		}
+	}
+	fmt.Println()
+
+	if output.Drifted == 0 {
+		printSuccess("Installed files match the record")
+	} else if !verifyInstallRestore {
+		fmt.Println("Restore them with: convex-backend-ops verify-install --restore")
+	}
+}
+
+// recordInstalledFiles records the checksums of the tracked files after an
+// operation wrote them
+func recordInstalledFiles(operation string) error {
+	version := ""
+	if manifest, err := readManifest("/var/lib/convex/manifest.json"); err == nil {
+		version = manifest.Version
+	}
+
+	record, err := hashInstalledFiles(trackedFiles)
+	if err != nil {
+		return err
+	}
+	record.RecordedAt = time.Now().UTC().Format(time.RFC3339)
+	record.Operation = operation
+	record.Version = version
+
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(installedFilesPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", installedFilesPath, err)
+	}
+	return nil
+}
+
+// recordInstalledFilesOrWarn records the installed files without failing an
+// operation that otherwise succeeded
+func recordInstalledFilesOrWarn(operation string) {
+	if err := recordInstalledFiles(operation); err != nil {
+		printError("Failed to record installed file checksums: %v", err)
+	}
+}
+
+func hashInstalledFiles(paths []string) (*InstalledFiles, error) {
+	record := &InstalledFiles{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat %s: %w", path, err)
+		}
+		sum, err := fileSHA256(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash %s: %w", path, err)
+		}
+		record.Files = append(record.Files, InstalledFile{
+			Path:   path,
+			SHA256: sum,
+			Size:   info.Size(),
+			Mode:   fmt.Sprintf("%04o", info.Mode().Perm()),
+		})
+	}
+	return record, nil
+}
+
+func readInstalledFiles(path string) (*InstalledFiles, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no installed file record at %s; it is written by install, upgrade and rollback", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var record InstalledFiles
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &record, nil
+}
+
+// checkDrift compares each recorded file with the file on disk
+func checkDrift(record *InstalledFiles) []FileDrift {
+	var drift []FileDrift
+	for _, f := range record.Files {
+		d := FileDrift{Path: f.Path, ExpectedSHA256: f.SHA256, Status: driftOK}
+
+		sum, err := fileSHA256(f.Path)
+		switch {
+		case os.IsNotExist(err):
+			d.Status = driftMissing
+		case err != nil:
+			d.Status = driftModified
+			d.Error = err.Error()
+		case sum != f.SHA256:
+			d.Status = driftModified
+			d.ActualSHA256 = sum
+		}
+		drift = append(drift, d)
+	}
+	return drift
+}
+
+// restoreCandidates lists where the expected content of each tracked file may
+// be found: re-rendered config, the given bundle, and the backups
+func restoreCandidates(bundlePath string) map[string][]restoreSource {
+	candidates := map[string][]restoreSource{
+		"/etc/convex/convex.env": {{name: "rendered config", content: []byte(envConfigContent)}},
+	}
+	if creds, err := readInstalledCredentials(); err == nil {
+		candidates["/etc/systemd/system/convex-backend.service"] = []restoreSource{
+			{name: "rendered unit", content: []byte(renderSystemdUnit(creds))},
+		}
+	}
+
+	var sources []restoreSource
+	if bundlePath != "" {
+		sources = append(sources, restoreSource{name: "bundle", path: bundlePath})
+	}
+	if backups, err := listBackups("/var/lib/convex/backups"); err == nil {
+		for _, b := range backups {
+			sources = append(sources, restoreSource{name: "backup " + filepath.Base(b.Path), path: b.Path})
+		}
+	}
+
+	// Bundles name the binary "backend", backups "convex-backend"
+	for _, src := range sources {
+		binary := "convex-backend"
+		if src.name == "bundle" {
+			binary = "backend"
+		}
+		candidates["/usr/local/bin/convex-backend"] = append(candidates["/usr/local/bin/convex-backend"],
+			restoreSource{name: src.name, path: filepath.Join(src.path, binary)})
+		candidates["/var/lib/convex/manifest.json"] = append(candidates["/var/lib/convex/manifest.json"],
+			restoreSource{name: src.name, path: filepath.Join(src.path, "manifest.json")})
+	}
+	return candidates
+}
+
+// findRestoreSource returns the first candidate with the expected checksum
+func findRestoreSource(expected string, candidates []restoreSource) (restoreSource, bool) {
+	for _, src := range candidates {
+		var sum string
+		if src.path != "" {
+			var err error
+			if sum, err = fileSHA256(src.path); err != nil {
+				continue
+			}
+		} else {
+			h := sha256.Sum256(src.content)
+			sum = hex.EncodeToString(h[:])
+		}
+		if sum == expected {
+			return src, true
+		}
+	}
+	return restoreSource{}, false
+}
+
+// restoreFile replaces path with the source content through a temporary file
+// and a rename, so a running binary can be replaced
+func restoreFile(path string, src restoreSource, mode string) error {
+	perm := os.FileMode(0644)
+	var parsed uint32
+	if _, err := fmt.Sscanf(mode, "%o", &parsed); err == nil {
+		perm = os.FileMode(parsed)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".restore-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if src.path != "" {
+		in, err := os.Open(src.path)
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		_, err = io.Copy(tmp, in)
+		in.Close()
+		if err != nil {
+			tmp.Close()
+			return fmt.Errorf("failed to copy %s: %w", src.path, err)
+		}
+	} else if _, err := tmp.Write(src.content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckDriftAndRestore(t *testing.T) {
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "convex-backend")
+	env := filepath.Join(dir, "convex.env")
+	manifest := filepath.Join(dir, "manifest.json")
+	os.WriteFile(binary, []byte("backend v1"), 0755)
+	os.WriteFile(env, []byte("RUST_LOG=info\n"), 0644)
+	os.WriteFile(manifest, []byte(`{"version": "1.0.0"}`), 0644)
+
+	record, err := hashInstalledFiles([]string{binary, env, manifest})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Files[0].Mode != "0755" {
+		t.Errorf("expected mode 0755 to be recorded, got %s", record.Files[0].Mode)
+	}
+
+	for _, d := range checkDrift(record) {
+		if d.Status != driftOK {
+			t.Fatalf("expected no drift, got %+v", d)
+		}
+	}
+
+	// Hand-patch the binary, edit the env file, delete the manifest
+	bundle := filepath.Join(dir, "bundle")
+	os.Mkdir(bundle, 0755)
+	os.WriteFile(filepath.Join(bundle, "backend"), []byte("backend v1"), 0755)
+	os.WriteFile(binary, []byte("patched"), 0700)
+	os.WriteFile(env, []byte("RUST_LOG=debug\n"), 0644)
+	os.Remove(manifest)
+
+	drift := checkDrift(record)
+	want := []string{driftModified, driftModified, driftMissing}
+	for i, d := range drift {
+		if d.Status != want[i] {
+			t.Errorf("%s: expected %s, got %s", d.Path, want[i], d.Status)
+		}
+	}
+	if drift[0].ActualSHA256 == "" || drift[0].ActualSHA256 == drift[0].ExpectedSHA256 {
+		t.Errorf("expected the actual checksum to be reported, got %+v", drift[0])
+	}
+
+	candidates := map[string][]restoreSource{
+		binary: {
+			{name: "backup v0.9.0", path: filepath.Join(dir, "missing")},
+			{name: "bundle", path: filepath.Join(bundle, "backend")},
+		},
+		env: {{name: "rendered config", content: []byte("RUST_LOG=warn\n")}},
+	}
+	remaining := restoreDrift(drift, record, candidates)
+	if remaining != 2 {
+		t.Errorf("expected the env file and manifest to remain drifted, got %d", remaining)
+	}
+	if !drift[0].Restored || drift[0].RestoredFrom != "bundle" {
+		t.Errorf("expected the binary to be restored from the bundle, got %+v", drift[0])
+	}
+	if drift[1].Restored || drift[1].Error == "" {
+		t.Errorf("expected a source with another checksum to be refused, got %+v", drift[1])
+	}
+
+	data, _ := os.ReadFile(binary)
+	info, _ := os.Stat(binary)
+	if string(data) != "backend v1" || info.Mode().Perm() != 0755 {
+		t.Errorf("expected the binary restored with mode 0755, got %q %04o", data, info.Mode().Perm())
+	}
+	if d := checkDrift(record); d[0].Status != driftOK {
+		t.Errorf("expected the restored binary to match the record, got %+v", d[0])
+	}
+}
+
+func TestReadInstalledFilesMissing(t *testing.T) {
+	if _, err := readInstalledFiles(filepath.Join(t.TempDir(), "installed-files.json")); err == nil {
+		t.Error("expected an error for a missing record")
+	}
+}
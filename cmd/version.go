@@ -11,11 +11,20 @@ import (
 
 // Manifest represents the installed manifest.json
 type Manifest struct {
-	Name      string   `json:"name"`
-	Version   string   `json:"version"`
-	Apps      []string `json:"apps"`
-	Platform  string   `json:"platform"`
-	CreatedAt string   `json:"createdAt"`
+	SchemaVersion int           `json:"schemaVersion,omitempty"`
+	Type          string        `json:"type,omitempty"`
+	Name          string        `json:"name"`
+	Version       string        `json:"version"`
+	AppsVersion   string        `json:"appsVersion,omitempty"`
+	Apps          []string      `json:"apps"`
+	Platform      string        `json:"platform"`
+	CreatedAt     string        `json:"createdAt"`
+	MinOpsVersion string        `json:"minOpsVersion,omitempty"`
+	UpgradeFrom   []string      `json:"upgradeFrom,omitempty"`
+	Health        *HealthConfig `json:"health,omitempty"`
+
+	GenerateCredentials bool   `json:"generateCredentials,omitempty"`
+	SecretsFrom         string `json:"secretsFrom,omitempty"`
 }
 
 // VersionOutput represents JSON output for version command
@@ -69,6 +78,9 @@ func runVersion(cmd *cobra.Command, args []string) error {
 		fmt.Println("Installed:")
 		fmt.Printf("  Name:    %s\n", output.Installed.Name)
 		fmt.Printf("  Version: %s\n", output.Installed.Version)
+		if output.Installed.AppsVersion != "" {
+			fmt.Printf("  Apps Version: %s\n", output.Installed.AppsVersion)
+		}
 		if len(output.Installed.Apps) > 0 {
 			fmt.Println("  Apps:")
 			for _, app := range output.Installed.Apps {
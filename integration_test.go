@@ -298,6 +298,45 @@ func TestUpgrade_SameVersion_Integration(t *testing.T) {
 	assert.Contains(t, output, "already at version")
 }
 
+// TestUpgrade_BlueGreen_Integration tests a blue/green upgrade validated on a staging port
+func TestUpgrade_BlueGreen_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	backendPath := filepath.Join("testdata", "sample-bundle", "backend")
+	if _, err := os.Stat(backendPath); os.IsNotExist(err) {
+		t.Skip("skipping: run convex-bundler first to get a real backend binary")
+	}
+
+	ctx := context.Background()
+
+	buildBinary(t)
+	container := startSystemdContainer(t, ctx)
+	defer container.Terminate(ctx)
+
+	copyBinaryToContainer(t, ctx, container)
+	copyBundleToContainer(t, ctx, container)
+
+	// Install first
+	exitCode, output := execInContainer(t, ctx, container, []string{
+		"/tmp/convex-backend-ops", "install", "--bundle", "/tmp/bundle", "--yes",
+	})
+	require.Equal(t, 0, exitCode, "install failed: %s", output)
+
+	// Upgrade with side-by-side validation
+	exitCode, output = execInContainer(t, ctx, container, []string{
+		"/tmp/convex-backend-ops", "upgrade", "--bundle", "/tmp/bundle", "--yes", "--force", "--blue-green",
+	})
+
+	assert.Equal(t, 0, exitCode, "upgrade failed: %s", output)
+	assert.Contains(t, output, "Upgraded")
+
+	// Staging copy should be cleaned up
+	exitCode, _ = execInContainer(t, ctx, container, []string{"test", "-d", "/var/lib/convex/staging"})
+	assert.NotEqual(t, 0, exitCode, "staging directory should be removed")
+}
+
 // TestRollback_Integration tests the rollback command
 func TestRollback_Integration(t *testing.T) {
 	if testing.Short() {